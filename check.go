@@ -0,0 +1,163 @@
+package teatree
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CheckState is the state of an item's checkbox when the tree is showing Checkboxes
+type CheckState int
+
+const (
+	Unchecked     CheckState = iota
+	Checked                  // the item, and everything below it, is checked
+	Indeterminate            // some of the item's children are checked, but not all of them
+)
+
+func (c CheckState) String() string {
+	return [...]string{
+		"unchecked",
+		"checked",
+		"indeterminate",
+	}[c]
+}
+
+// checkbox returns how the state is drawn in front of the item's icon
+func (c CheckState) checkbox() string {
+	return [...]string{
+		"[ ]",
+		"[x]",
+		"[-]",
+	}[c]
+}
+
+// CheckChangedMsg is returned from Tree.Update when the user checks or unchecks an item
+type CheckChangedMsg struct {
+	Item  *TreeItem
+	State CheckState
+}
+
+// CheckState returns whether this item is checked. An item with loaded children is Checked only
+// when all of them are, and Indeterminate when some of them are.
+func (ti *TreeItem) CheckState() CheckState {
+	return ti.check
+}
+
+// SetChecked checks or unchecks the item along with all of its loaded children. Children that
+// are loaded later by an OpenFunc pick up the state when they are added. The ancestors of the
+// item are updated to reflect the change.
+func (ti *TreeItem) SetChecked(checked bool) {
+	state := Unchecked
+	if checked {
+		state = Checked
+	}
+	ti.setCheckTree(state)
+	ti.updateAncestorChecks()
+}
+
+func (ti *TreeItem) setCheckTree(state CheckState) {
+	ti.check = state
+	for _, child := range ti.Children {
+		child.setCheckTree(state)
+	}
+}
+
+// deriveCheck works out this item's state from its direct children. Items without loaded
+// children keep their own state, except that one left indeterminate by children that have all
+// gone becomes unchecked, as only some of them were checked.
+func (ti *TreeItem) deriveCheck() {
+	if len(ti.Children) == 0 {
+		if ti.check == Indeterminate {
+			ti.check = Unchecked
+		}
+		return
+	}
+	var checked, unchecked bool
+	for _, child := range ti.Children {
+		switch child.check {
+		case Checked:
+			checked = true
+		case Unchecked:
+			unchecked = true
+		default:
+			checked, unchecked = true, true
+		}
+	}
+	switch {
+	case checked && unchecked:
+		ti.check = Indeterminate
+	case checked:
+		ti.check = Checked
+	default:
+		ti.check = Unchecked
+	}
+}
+
+func (ti *TreeItem) updateAncestorChecks() {
+	for par, ok := ti.Parent.(*TreeItem); ok; par, ok = par.Parent.(*TreeItem) {
+		par.deriveCheck()
+	}
+}
+
+// adoptChecks is called when children are added. If this item was checked, the new children
+// are checked too, otherwise this item's state is worked out again from the children.
+func (ti *TreeItem) adoptChecks(children []*TreeItem) {
+	if ti.check == Checked {
+		for _, child := range children {
+			child.setCheckTree(Checked)
+		}
+		return
+	}
+	// Unchecked children can't change an unchecked or indeterminate parent, so there's no need
+	// to look at all of the siblings again
+	for _, child := range children {
+		if child.check != Unchecked {
+			ti.deriveCheck()
+			ti.updateAncestorChecks()
+			return
+		}
+	}
+	if len(ti.Children) == len(children) {
+		// These are the first children, so the item's own state no longer applies
+		ti.deriveCheck()
+		ti.updateAncestorChecks()
+	}
+}
+
+// ToggleCheck checks the active item, or unchecks it if it was already fully checked. The
+// returned command delivers a CheckChangedMsg.
+func (t *Tree) ToggleCheck() tea.Cmd {
+	ti := t.ActiveItem
+	if ti == nil || ti.placeholder != statusNone {
+		return nil
+	}
+	ti.SetChecked(ti.check != Checked)
+	msg := CheckChangedMsg{
+		Item:  ti,
+		State: ti.check,
+	}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// CheckedItems returns every loaded item that is fully checked, in tree order. A checked item
+// whose children haven't been loaded yet stands for all of them.
+func (t *Tree) CheckedItems() []*TreeItem {
+	var checked []*TreeItem
+	for _, item := range loadedItems(nil, t.Items) {
+		if item.check == Checked {
+			checked = append(checked, item)
+		}
+	}
+	return checked
+}
+
+// onCheckbox reports whether column x of a rendered row falls on the item's checkbox
+func (ti *TreeItem) onCheckbox(x int) bool {
+	if !ti.ParentTree.Checkboxes {
+		return false
+	}
+	start := ti.rowDepth*ti.ParentTree.indentWidth() + ti.ParentTree.chevronWidth()
+	return x >= start && x < start+lipgloss.Width(Unchecked.checkbox())
+}
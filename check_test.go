@@ -0,0 +1,140 @@
+package teatree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckParentChecksDescendants(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+	openAll(tr.Items)
+
+	_, cmd := tr.Update(keyMsg(" "))
+	if cmd == nil {
+		t.Fatal("expected a command when checking an item")
+	}
+	if msg, ok := cmd().(CheckChangedMsg); !ok || msg.Item != tr.Items[0] || msg.State != Checked {
+		t.Fatalf("unexpected message %#v", cmd())
+	}
+	want := []string{"Item 1", "Sub 1", "SubSub 1"}
+	if got := names(tr.CheckedItems()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if !tr.Items[0].Open {
+		t.Fatal("expected space not to close the item when showing checkboxes")
+	}
+}
+
+func TestCheckIndeterminate(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+	openAll(tr.Items)
+
+	find(tr, "Item 2", "AA").SetChecked(true)
+	if got := tr.Items[1].CheckState(); got != Indeterminate {
+		t.Fatalf("expected Item 2 to be indeterminate, got %s", got)
+	}
+	if !strings.Contains(tr.View(), "[-]") {
+		t.Fatal("expected the indeterminate box to be drawn")
+	}
+
+	find(tr, "Item 2", "BB").SetChecked(true)
+	if got := tr.Items[1].CheckState(); got != Checked {
+		t.Fatalf("expected Item 2 to be checked, got %s", got)
+	}
+
+	// Unchecking a grandchild makes every ancestor indeterminate
+	tr.Items[0].SetChecked(true)
+	find(tr, "Item 1", "Sub 1", "SubSub 1").SetChecked(false)
+	if got := find(tr, "Item 1", "Sub 1").CheckState(); got != Unchecked {
+		t.Fatalf("expected Sub 1 to be unchecked, got %s", got)
+	}
+	if got := tr.Items[0].CheckState(); got != Unchecked {
+		t.Fatalf("expected Item 1 to be unchecked, got %s", got)
+	}
+}
+
+func TestCheckLazyChildren(t *testing.T) {
+	var loads int
+	tr := newLazyTree(&loads)
+	tr.Checkboxes = true
+
+	home := tr.Items[1]
+	home.SetChecked(true)
+	if _, err := tr.Reveal([]string{"home", "cfox", "work", "testproj"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ti := range find(tr, "home", "cfox", "work").Children {
+		if ti.CheckState() != Checked {
+			t.Fatalf("expected lazily loaded %s to be checked", ti.Name)
+		}
+	}
+
+	// Unchecking a lazily loaded child leaves its ancestors partly checked
+	find(tr, "home", "cfox", "work", "mgrthing").SetChecked(false)
+	for _, ti := range []*TreeItem{home, find(tr, "home", "cfox"), find(tr, "home", "cfox", "work")} {
+		if ti.CheckState() != Indeterminate {
+			t.Fatalf("expected %s to be indeterminate, got %s", ti.Name, ti.CheckState())
+		}
+	}
+}
+
+func TestCheckClick(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+
+	// Item 2 is on line 1, its box starts after the chevron
+	tr.Update(click(2, 1))
+	if tr.Items[1].CheckState() != Checked {
+		t.Fatalf("expected a click on the box to check Item 2, got %s", tr.Items[1].CheckState())
+	}
+	tr.Update(click(8, 1))
+	if tr.Items[1].CheckState() != Checked {
+		t.Fatal("expected a click on the label to leave the box alone")
+	}
+}
+
+func TestCheckAncestorsFollowChildren(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+	openAll(tr.Items)
+
+	// An indeterminate item whose children have all gone is unchecked
+	item2 := find(tr, "Item 2")
+	aa := find(tr, "Item 2", "AA")
+	aa.AddChildren(item("x"), item("y"))
+	find(tr, "Item 2", "AA", "x").SetChecked(true)
+	item2.Remove(find(tr, "Item 2", "BB"))
+	if got := item2.CheckState(); got != Indeterminate {
+		t.Fatalf("expected Item 2 to be indeterminate, got %s", got)
+	}
+	item2.Remove(aa)
+	if got := item2.CheckState(); got != Unchecked {
+		t.Fatalf("expected Item 2 to be unchecked without children, got %s", got)
+	}
+
+	// Children read again after a Refresh decide the item's state, and so its ancestors'
+	sub := NewItem("sub", true, nil, nil, nil, nil, func(ti *TreeItem) {
+		if len(ti.Children) == 0 {
+			ti.AddChildren(item("a"), item("b"))
+		}
+	}, nil, nil)
+	root := item("root", sub, item("c"))
+	tr.AddChildren(root)
+	root.ToggleChildren()
+	sub.ToggleChildren()
+	find(tr, "root", "sub", "a").SetChecked(true)
+	if root.CheckState() != Indeterminate || sub.CheckState() != Indeterminate {
+		t.Fatalf("expected root and sub to be indeterminate, got %s and %s", root.CheckState(), sub.CheckState())
+	}
+	sub.Refresh()
+	sub.ToggleChildren()
+	if sub.CheckState() != Unchecked || root.CheckState() != Unchecked {
+		t.Fatalf("expected root and sub to be unchecked, got %s and %s", root.CheckState(), sub.CheckState())
+	}
+	if strings.Contains(tr.View(), "[-]") {
+		t.Fatal("expected no indeterminate boxes to be left")
+	}
+}
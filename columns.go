@@ -0,0 +1,131 @@
+package teatree
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// Column is one of the extra columns of a tree-table, like the size or modification time of a
+// file. They are drawn to the right of the tree, under a header that stays put while the rows
+// scroll.
+type Column struct {
+	Title string
+	Width int                       // Width is how many terminal columns the column takes up
+	Flex  int                       // If Width isn't set, the column shares out the space that's left in proportion to Flex. The tree itself has a Flex of 1.
+	Align lipgloss.Position         // lipgloss.Left, (the default), or lipgloss.Right, which suits numbers
+	Value func(*TreeItem) string    // Value returns what is shown in the column for an item
+	Less  func(a, b *TreeItem) bool // Less, if set, is used to sort by the column, instead of comparing Values
+}
+
+// DefaultNameTitle is the header of the tree's own column, if Tree.NameTitle isn't set
+const DefaultNameTitle = "Name"
+
+// columnGap is the space between columns
+const columnGap = " "
+
+// tableWidth is the width the columns are fitted into. A tree that hasn't been given a size is
+// treated as if it were on a standard terminal.
+func (t *Tree) tableWidth() int {
+	if w := t.innerWidth(); w > 0 {
+		return w
+	}
+	return 80
+}
+
+// columnWidths works out how wide the tree and each of the Columns are. Columns with a Width get
+// it, and everything else shares out what's left.
+func (t *Tree) columnWidths() (name int, widths []int) {
+	left := t.tableWidth()
+	flex := 1
+	widths = make([]int, len(t.Columns))
+	for x, c := range t.Columns {
+		left -= len(columnGap)
+		if c.Width > 0 {
+			widths[x] = c.Width
+			left -= c.Width
+		} else {
+			flex += columnFlex(c)
+		}
+	}
+	if left < 0 {
+		left = 0
+	}
+	name = left
+	for x, c := range t.Columns {
+		if c.Width <= 0 {
+			widths[x] = left * columnFlex(c) / flex
+			name -= widths[x]
+		}
+	}
+	return name, widths
+}
+
+func columnFlex(c Column) int {
+	if c.Flex > 0 {
+		return c.Flex
+	}
+	return 1
+}
+
+// fitCell truncates or pads a rendered string to exactly w columns. The padding is drawn with
+// pad, so that the cursor runs the whole width of the row.
+func fitCell(s string, w int, align lipgloss.Position, pad lipgloss.Style) string {
+	if w <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) > w {
+		s = truncate.StringWithTail(s, uint(w), "…")
+	}
+	gap := w - lipgloss.Width(s)
+	if gap <= 0 {
+		return s
+	}
+	spaces := pad.Render(strings.Repeat(" ", gap))
+	if align == lipgloss.Right {
+		return spaces + s
+	}
+	return s + spaces
+}
+
+// renderCells lays a rendered row out as a line of the table, with the item's Column values
+func (t *Tree) renderCells(ti *TreeItem, row string, style lipgloss.Style) string {
+	name, widths := t.columnWidths()
+	var b strings.Builder
+	b.WriteString(fitCell(row, name, lipgloss.Left, style))
+	for x, c := range t.Columns {
+		var value string
+		if c.Value != nil && ti.placeholder == statusNone {
+			value = c.Value(ti)
+		}
+		b.WriteString(style.Render(columnGap))
+		b.WriteString(fitCell(style.Render(value), widths[x], c.Align, style))
+	}
+	return b.String()
+}
+
+// renderHeader draws the column titles, with an arrow on the one the tree is sorted by
+func (t *Tree) renderHeader() string {
+	title := func(s string, column int) string {
+		if t.sorted && t.sortColumn == column {
+			if t.sortDescending {
+				return s + " " + t.Glyphs.SortDescending
+			}
+			return s + " " + t.Glyphs.SortAscending
+		}
+		return s
+	}
+	nameTitle := t.NameTitle
+	if nameTitle == "" {
+		nameTitle = DefaultNameTitle
+	}
+	name, widths := t.columnWidths()
+	var b strings.Builder
+	b.WriteString(fitCell(title(nameTitle, NameColumn), name, lipgloss.Left, lipgloss.NewStyle()))
+	for x, c := range t.Columns {
+		b.WriteString(columnGap)
+		b.WriteString(fitCell(title(c.Title, x), widths[x], c.Align, lipgloss.NewStyle()))
+	}
+	return t.Styles.Header.Render(b.String())
+}
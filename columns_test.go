@@ -0,0 +1,126 @@
+package teatree
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newTableTree is the todo tree with a size for each item, which is the length of its name
+func newTableTree(width, height int) *Tree {
+	tr := newTodoTree(height)
+	tr.SetGlyphs(ASCIIGlyphs)
+	tr.Width = width
+	size := func(ti *TreeItem) int { return len(ti.Name) }
+	tr.Columns = []Column{
+		{
+			Title: "Size",
+			Width: 4,
+			Align: lipgloss.Right,
+			Value: func(ti *TreeItem) string { return strconv.Itoa(size(ti)) },
+			Less:  func(a, b *TreeItem) bool { return size(a) < size(b) },
+		},
+		{
+			Title: "Kind",
+			Flex:  1,
+			Value: func(ti *TreeItem) string {
+				if ti.CanHaveChildren {
+					return "folder"
+				}
+				return "file"
+			},
+		},
+	}
+	return tr
+}
+
+func TestColumns(t *testing.T) {
+	tr := newTableTree(30, 4)
+	openAll(tr.Items)
+
+	// The 30 columns go to the two gaps, the Size column's 4, and the rest is shared out evenly
+	// between the tree and the Kind column
+	want := []string{
+		"Name         Size Kind",
+		"- Item 1        6 folder",
+		"  - Sub 1       5 folder",
+		"      SubSu…    8 file",
+	}
+	got := renderedLines(tr)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	for x, line := range strings.Split(tr.View(), "\n")[1:] {
+		if w := lipgloss.Width(line); w != 30 {
+			t.Fatalf("expected row %d to fill the width, it is %d wide", x, w)
+		}
+	}
+
+	// The header stays put as the rows scroll
+	tr.SelectLast()
+	if got := renderedLines(tr); got[0] != want[0] || !strings.HasPrefix(got[3], "    CC") {
+		t.Fatalf("expected the header above the last rows, got %q", got)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestCycleSort(t *testing.T) {
+	tr := newTableTree(40, 20)
+	openAll(tr.Items)
+	find(tr, "Item 2").AddChildren(item("b"), item("Ccc"))
+
+	names := func(items []*TreeItem) string {
+		var s []string
+		for _, item := range items {
+			s = append(s, item.Name)
+		}
+		return strings.Join(s, " ")
+	}
+	item2 := find(tr, "Item 2")
+
+	tr.Update(keyMsg("s"))
+	if got := names(item2.Children); got != "AA b BB Ccc" {
+		t.Fatalf("expected the children sorted by name, got %s", got)
+	}
+	if got := renderedLines(tr)[0]; !strings.HasPrefix(got, "Name ^") {
+		t.Fatalf("expected the Name header to show the sort, got %q", got)
+	}
+	tr.Update(keyMsg("s"))
+	if got := names(item2.Children); got != "Ccc BB b AA" {
+		t.Fatalf("expected the children sorted by name, descending, got %s", got)
+	}
+	if got := names(tr.Items); got != "Item 3 Item 2 Item 1" {
+		t.Fatalf("expected the top level to be sorted too, got %s", got)
+	}
+
+	// The sort is stable, so BB stays ahead of AA, which is the same size
+	tr.Update(keyMsg("s"))
+	if got := names(item2.Children); got != "b BB AA Ccc" {
+		t.Fatalf("expected the children sorted by size, got %s", got)
+	}
+	if col, desc, ok := tr.SortColumn(); !ok || col != 0 || desc {
+		t.Fatalf("expected to be sorted by column 0, ascending, got %d %v %v", col, desc, ok)
+	}
+
+	// New children go into their place
+	item2.AddChildren(item("Dddd"), item("e"))
+	if got := names(item2.Children); got != "b e BB AA Ccc Dddd" {
+		t.Fatalf("expected new children to be sorted in, got %s", got)
+	}
+
+	// Kind, ascending and descending, then back to the order they were added in, and then
+	// round to the names again
+	for x := 0; x < 4; x++ {
+		tr.Update(keyMsg("s"))
+	}
+	if _, _, ok := tr.SortColumn(); ok {
+		t.Fatal("expected the sort to be cleared after the last column")
+	}
+	tr.Update(keyMsg("s"))
+	if col, desc, _ := tr.SortColumn(); col != NameColumn || desc {
+		t.Fatalf("expected the sort to cycle back to the names, got %d %v", col, desc)
+	}
+	checkActiveOnScreen(t, tr)
+}
@@ -0,0 +1,293 @@
+package teatree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrMoveIntoSelf is returned by MoveTo when the new parent is the item itself, or one of its
+// descendants
+var ErrMoveIntoSelf = errors.New("can't move an item into itself")
+
+// InsertAt adds children to the item, starting at the given position among its children. An
+// index past either end adds them at that end. If the item's children are kept sorted, (see
+// Tree.Less), they go into their sorted places instead. The active item stays on the same line
+// of the screen.
+func (ti *TreeItem) InsertAt(index int, children ...*TreeItem) ItemHolder {
+	return ti.insertAt(index, children, true)
+}
+
+// insertAt is InsertAt. adopt says whether the children pick up the item's checkbox, which new
+// children do, but ones being moved here keep their own.
+func (ti *TreeItem) insertAt(index int, children []*TreeItem, adopt bool) ItemHolder {
+	if len(children) == 0 {
+		return ti
+	}
+	tree := ti.ParentTree
+	if tree == nil || !(ti.Open || tree.filterShown != nil) {
+		// Children added to a closed item don't change what's on screen, which keeps loading a
+		// big directory one item at a time from rebuilding the rows for each one
+		ti.insert(index, children, adopt)
+		return ti
+	}
+	tree.holdActiveLine(func() {
+		ti.insert(index, children, adopt)
+	})
+	return ti
+}
+
+func (ti *TreeItem) insert(index int, children []*TreeItem, adopt bool) {
+	tree := ti.ParentTree
+	ti.Lock()
+	if tree != nil && tree.lessFor(ti) != nil {
+		ti.Children = append(ti.Children, children...)
+		tree.insertSorted(ti, ti.Children, len(ti.Children)-len(children))
+	} else {
+		ti.Children = insertItems(ti.Children, index, children)
+	}
+	ti.Unlock()
+	ti.CanHaveChildren = true // If it wasn't set before, it will be now
+
+	for _, child := range children {
+		child.Parent = ti
+		child.setParentTree(tree)
+	}
+	if adopt {
+		ti.adoptChecks(children)
+	} else {
+		ti.deriveCheck()
+		ti.updateAncestorChecks()
+	}
+}
+
+// InsertAt adds top level items to the tree, starting at the given position. Like
+// TreeItem.InsertAt, sorted trees put them in their sorted places instead.
+func (t *Tree) InsertAt(index int, items ...*TreeItem) ItemHolder {
+	if len(items) == 0 {
+		return t
+	}
+	t.holdActiveLine(func() {
+		t.insert(index, items)
+	})
+	return t
+}
+
+func (t *Tree) insert(index int, items []*TreeItem) {
+	t.Lock()
+	if t.lessFor(nil) != nil {
+		t.Items = append(t.Items, items...)
+		t.insertSorted(nil, t.Items, len(t.Items)-len(items))
+	} else {
+		t.Items = insertItems(t.Items, index, items)
+	}
+	t.Unlock()
+	for _, item := range items {
+		item.Parent = t
+		item.setParentTree(t)
+	}
+	if t.ActiveItem == nil {
+		t.ActiveItem = t.Items[0]
+	}
+}
+
+// Remove takes children out of the item, along with everything below them, see Detach. Items
+// that aren't its children are left alone. The children can be a slice of the item's own
+// Children, such as all of them.
+func (ti *TreeItem) Remove(children ...*TreeItem) {
+	// Each Detach takes its item out of Children, which may be what's being looped over
+	for _, child := range slices.Clone(children) {
+		if child.Parent == ItemHolder(ti) {
+			child.Detach()
+		}
+	}
+}
+
+// Remove takes top level items out of the tree, along with everything below them, see Detach.
+// Items that aren't at the top level are left alone. The items can be a slice of the tree's own
+// Items.
+func (t *Tree) Remove(items ...*TreeItem) {
+	for _, item := range slices.Clone(items) {
+		if item.Parent == ItemHolder(t) {
+			item.Detach()
+		}
+	}
+}
+
+// Detach takes the item, and everything below it, out of its parent and out of the tree, so it
+// can be thrown away or added somewhere else. If the active item was in there, the cursor moves
+// to the next sibling, or if there isn't one, to the previous sibling or the parent. Any load
+// that was running below the item is forgotten, and an item whose children hadn't arrived yet is
+// closed, so opening it again loads them.
+func (ti *TreeItem) Detach() {
+	tree := ti.ParentTree
+	if tree == nil {
+		ti.unlink()
+		return
+	}
+	tree.holdActiveLine(func() {
+		tree.moveActiveOutOf(ti)
+		ti.unlink()
+		tree.forget(ti)
+	})
+	ti.setParentTree(nil)
+}
+
+// MoveTo moves the item, and everything below it, to newParent, (either an item or a Tree),
+// where it ends up at the given position among its new siblings. It can be moved to another tree
+// too. The item stays active if it was, as long as its new parent is open; otherwise the cursor
+// goes to the nearest visible ancestor. It keeps its checkbox, and the new parent's is worked out
+// again from its children.
+func (ti *TreeItem) MoveTo(newParent ItemHolder, index int) error {
+	var newTree *Tree
+	switch p := newParent.(type) {
+	case *Tree:
+		newTree = p
+	case *TreeItem:
+		for anc := p; anc != nil; anc, _ = anc.Parent.(*TreeItem) {
+			if anc == ti {
+				return fmt.Errorf("teatree: moving %q under %q: %w", ti.Name, p.Name, ErrMoveIntoSelf)
+			}
+		}
+		newTree = p.ParentTree
+	default:
+		return fmt.Errorf("teatree: can't move %q to a %T", ti.Name, newParent)
+	}
+
+	if ti.ParentTree != newTree || newTree == nil {
+		// Leaving the tree, so it's a detach from the old one and an insert into the new one
+		ti.Detach()
+		if p, ok := newParent.(*TreeItem); ok {
+			p.insertAt(index, []*TreeItem{ti}, false)
+		} else {
+			newTree.InsertAt(index, ti)
+		}
+		return nil
+	}
+
+	newTree.holdActiveLine(func() {
+		ti.unlink()
+		if p, ok := newParent.(*TreeItem); ok {
+			p.insert(index, []*TreeItem{ti}, false)
+		} else {
+			newTree.insert(index, []*TreeItem{ti})
+		}
+	})
+	return nil
+}
+
+// unlink takes the item out of its parent's list of children, and works out the parent's
+// checkbox again without it
+func (ti *TreeItem) unlink() {
+	switch p := ti.Parent.(type) {
+	case *Tree:
+		p.Lock()
+		p.Items = removeItems(p.Items, []*TreeItem{ti})
+		p.Unlock()
+	case *TreeItem:
+		p.Lock()
+		p.Children = removeItems(p.Children, []*TreeItem{ti})
+		p.Unlock()
+		p.deriveCheck()
+		p.updateAncestorChecks()
+	}
+	ti.Parent = nil
+}
+
+// moveActiveOutOf moves the cursor off an item that is about to go, and everything below it, to
+// the next sibling that is on screen, the previous one, or the parent
+func (t *Tree) moveActiveOutOf(ti *TreeItem) {
+	if !ti.contains(t.ActiveItem) {
+		return
+	}
+	var siblings []*TreeItem
+	par, _ := ti.Parent.(*TreeItem)
+	if par != nil {
+		siblings = t.displayedChildren(par)
+	} else {
+		siblings = t.displayed(t.Items)
+	}
+	x := 0
+	for x < len(siblings) && siblings[x] != ti {
+		x++
+	}
+	switch {
+	case x+1 < len(siblings):
+		t.ActiveItem = siblings[x+1]
+	case x > 0 && x < len(siblings):
+		t.ActiveItem = siblings[x-1]
+	case par != nil:
+		t.ActiveItem = par
+	default:
+		t.ActiveItem = nil
+	}
+}
+
+// contains reports whether other is the item, or somewhere below it. The loading and error rows
+// count as being below the item they belong to.
+func (ti *TreeItem) contains(other *TreeItem) bool {
+	for other != nil {
+		if other == ti {
+			return true
+		}
+		other, _ = other.Parent.(*TreeItem)
+	}
+	return false
+}
+
+// forget drops everything the tree was holding on to from an item that has been taken out of it,
+// and everything below it
+func (t *Tree) forget(ti *TreeItem) {
+	if ti.loading && !ti.loaded {
+		// Its children never arrived, so close it, and opening it again loads them
+		ti.Open = false
+	}
+	t.cancelLoad(ti)
+	if t.markAnchor == ti {
+		t.markAnchor = nil
+	}
+	if t.lastClickItem == ti {
+		t.lastClickItem = nil
+	}
+	if t.filterPrevActive == ti {
+		t.filterPrevActive = nil
+	}
+	for _, child := range ti.Children {
+		t.forget(child)
+	}
+}
+
+// insertItems returns list with items inserted at index, which is clamped to the ends of list
+func insertItems(list []*TreeItem, index int, items []*TreeItem) []*TreeItem {
+	if index < 0 {
+		index = 0
+	}
+	if index > len(list) {
+		index = len(list)
+	}
+	list = append(list, items...)
+	copy(list[index+len(items):], list[index:len(list)-len(items)])
+	copy(list[index:], items)
+	return list
+}
+
+// removeItems returns list without items, keeping the order of what's left
+func removeItems(list []*TreeItem, items []*TreeItem) []*TreeItem {
+	kept := list[:0]
+	for _, item := range list {
+		drop := false
+		for _, gone := range items {
+			if item == gone {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, item)
+		}
+	}
+	for x := len(kept); x < len(list); x++ {
+		list[x] = nil
+	}
+	return kept
+}
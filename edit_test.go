@@ -0,0 +1,229 @@
+package teatree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInsertAt(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+	item2 := find(tr, "Item 2")
+
+	item2.InsertAt(1, item("A1", item("A1a")))
+	item2.InsertAt(-5, item("First"))
+	item2.InsertAt(99, item("Last"))
+	if got := joined(item2.Children); got != "First AA A1 BB Last" {
+		t.Fatalf("expected the children inserted in place, got %s", got)
+	}
+	a1a := find(tr, "Item 2", "A1", "A1a")
+	if a1a.ParentTree != tr || a1a.Parent != ItemHolder(find(tr, "Item 2", "A1")) {
+		t.Fatal("expected the inserted subtree to be attached to the tree")
+	}
+
+	tr.InsertAt(0, item("Item 0"))
+	if got := joined(tr.Items); got != "Item 0 Item 1 Item 2 Item 3" {
+		t.Fatalf("expected a new top level item first, got %s", got)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestRemoveMovesActiveToSibling(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+	item2 := find(tr, "Item 2")
+	aa := find(tr, "Item 2", "AA")
+	tr.SetActive(aa)
+
+	item2.Remove(aa)
+	if tr.ActiveItem.Name != "BB" {
+		t.Fatalf("expected the next sibling to become active, got %s", tr.ActiveItem.Name)
+	}
+	if aa.Parent != nil || aa.ParentTree != nil {
+		t.Fatal("expected the removed item to be cut loose from the tree")
+	}
+
+	find(tr, "Item 2", "BB").Detach()
+	if tr.ActiveItem != item2 {
+		t.Fatalf("expected the parent to become active once it had no children, got %s", tr.ActiveItem.Name)
+	}
+
+	// Removing a whole subtree with the active item deep inside it
+	tr.SetActive(find(tr, "Item 1", "Sub 1", "SubSub 1"))
+	tr.Remove(find(tr, "Item 1"))
+	if tr.ActiveItem != item2 {
+		t.Fatalf("expected the next top level item to become active, got %s", tr.ActiveItem.Name)
+	}
+	tr.Remove(find(tr, "Item 3"))
+	if tr.ActiveItem != item2 {
+		t.Fatalf("expected removing something else to leave the active item alone, got %s", tr.ActiveItem.Name)
+	}
+	if got := joined(tr.Items); got != "Item 2" {
+		t.Fatalf("expected only Item 2 to be left, got %s", got)
+	}
+	checkActiveOnScreen(t, tr)
+
+	tr.Remove(item2)
+	if tr.ActiveItem != nil || len(tr.visibleItems()) != 0 {
+		t.Fatal("expected an empty tree to have no active item")
+	}
+}
+
+func TestRemoveOwnChildren(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+	item2 := find(tr, "Item 2")
+	item2.AddChildren(item("CC"))
+
+	// A slice of the children themselves, which shrinks as they go
+	item2.Remove(item2.Children[0:2]...)
+	if got := joined(item2.Children); got != "CC" {
+		t.Fatalf("expected AA and BB to be removed, got %s", got)
+	}
+	item2.Remove(item2.Children...)
+	if len(item2.Children) != 0 {
+		t.Fatalf("expected every child to be removed, got %s", joined(item2.Children))
+	}
+	tr.Remove(tr.Items...)
+	if len(tr.Items) != 0 || tr.ActiveItem != nil {
+		t.Fatalf("expected every item to be removed, got %s", joined(tr.Items))
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+	sub := find(tr, "Item 1", "Sub 1")
+	tr.SetActive(sub)
+
+	if err := sub.MoveTo(find(tr, "Item 2"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := joined(find(tr, "Item 2").Children); got != "AA Sub 1 BB" {
+		t.Fatalf("expected Sub 1 to be moved between AA and BB, got %s", got)
+	}
+	if len(find(tr, "Item 1").Children) != 0 || sub.Parent != ItemHolder(find(tr, "Item 2")) {
+		t.Fatal("expected Sub 1 to have left Item 1")
+	}
+	if tr.ActiveItem != sub {
+		t.Fatalf("expected Sub 1 to stay active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+
+	// Into a closed item, which hides it
+	find(tr, "Item 3").Open = false
+	if err := sub.MoveTo(find(tr, "Item 3"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if tr.ActiveItem.Name != "Item 3" {
+		t.Fatalf("expected the closed parent to become active, got %s", tr.ActiveItem.Name)
+	}
+
+	if err := find(tr, "Item 3").MoveTo(find(tr, "Item 3", "Sub 1", "SubSub 1"), 0); !errors.Is(err, ErrMoveIntoSelf) {
+		t.Fatalf("expected ErrMoveIntoSelf, got %v", err)
+	}
+
+	// To another tree
+	other := newTodoTree(10)
+	if err := sub.MoveTo(other, 0); err != nil {
+		t.Fatal(err)
+	}
+	if sub.ParentTree != other || find(other, "Sub 1", "SubSub 1").ParentTree != other {
+		t.Fatal("expected the moved subtree to belong to the other tree")
+	}
+	if len(find(tr, "Item 3").Children) != 1 {
+		t.Fatal("expected Sub 1 to have left the first tree")
+	}
+}
+
+func TestRemoveUpdatesChecks(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+	item2 := find(tr, "Item 2")
+	find(tr, "Item 2", "AA").SetChecked(true)
+	if item2.CheckState() != Indeterminate {
+		t.Fatalf("expected Item 2 to be indeterminate, got %v", item2.CheckState())
+	}
+	item2.Remove(find(tr, "Item 2", "BB"))
+	if item2.CheckState() != Checked {
+		t.Fatalf("expected Item 2 to be checked once only checked children are left, got %v", item2.CheckState())
+	}
+}
+
+func TestDetachForgetsLoads(t *testing.T) {
+	tr := newTodoTree(10)
+	ti := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+	tr.AddChildren(ti)
+	ti.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) { return nil, nil })
+	ti.ToggleChildren()
+	if tr.loadsInFlight != 1 {
+		t.Fatalf("expected a load to be running, got %d", tr.loadsInFlight)
+	}
+	ti.Detach()
+	if tr.loadsInFlight != 0 {
+		t.Fatalf("expected the load to be forgotten, got %d running", tr.loadsInFlight)
+	}
+}
+
+func TestMoveToWhileLoading(t *testing.T) {
+	tr := newTodoTree(10)
+	ti := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+	ti.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) {
+		return []*TreeItem{item("child")}, nil
+	})
+	tr.AddChildren(ti)
+	ti.ToggleChildren()
+	cmd := tr.FlushCmds()
+
+	other := newTodoTree(10)
+	if err := ti.MoveTo(other, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ti.Loading() || tr.loadsInFlight != 0 || other.loadsInFlight != 0 {
+		t.Fatal("expected the load to be forgotten")
+	}
+	for _, line := range viewLines(other) {
+		if strings.Contains(line, "loading") {
+			t.Fatalf("expected no loading row to be left behind, got %q", line)
+		}
+	}
+
+	// The old load's result is dropped, and opening the item again loads it in its new tree
+	loaded, _ := loadedMsg(runCmd(cmd))
+	other.Update(loaded)
+	if len(ti.Children) != 0 || ti.Open {
+		t.Fatal("expected the item to be closed, with the old load dropped")
+	}
+	ti.ToggleChildren()
+	deliver(t, other, other.FlushCmds())
+	if joined(ti.Children) != "child" {
+		t.Fatalf("expected the children to load in the new tree, got %s", joined(ti.Children))
+	}
+}
+
+func TestMoveToKeepsCheck(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Checkboxes = true
+	item2 := find(tr, "Item 2")
+	item2.SetChecked(true)
+	cc := find(tr, "Item 3", "CC")
+	if err := cc.MoveTo(item2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if cc.CheckState() != Unchecked || item2.CheckState() != Indeterminate {
+		t.Fatalf("expected CC to stay unchecked and Item 2 to be indeterminate, got %v and %v", cc.CheckState(), item2.CheckState())
+	}
+
+	// Into another tree too
+	other := newTodoTree(10)
+	other.Checkboxes = true
+	dest := find(other, "Item 1")
+	dest.SetChecked(true)
+	if err := cc.MoveTo(dest, 0); err != nil {
+		t.Fatal(err)
+	}
+	if cc.CheckState() != Unchecked || dest.CheckState() != Indeterminate || item2.CheckState() != Checked {
+		t.Fatalf("expected CC to stay unchecked, got %v, %v and %v", cc.CheckState(), dest.CheckState(), item2.CheckState())
+	}
+}
@@ -1,34 +1,41 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/greenenergy/teatree"
+	"github.com/greenenergy/teatree/fstree"
 )
 
-const IconFolder = "\U000F024B"
-const IconFile = "\U000F0214"
-
 const GoGopherDev = "\ue626"
 const GoGopher = "\ue724"
 const GoTitle = "\U000F07D3"
 
 type FileBrowserModel struct {
 	dir      string
+	files    *fstree.Provider
 	Tree     *teatree.Tree
-	info     func()
 	quitting bool
 }
 
+var (
+	refreshKey = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh"))
+	hiddenKey  = key.NewBinding(key.WithKeys("H"), key.WithHelp("H", "hidden files"))
+	quitKey    = key.NewBinding(key.WithKeys("ctrl+c", "q"), key.WithHelp("q", "quit"))
+)
+
 func (fm *FileBrowserModel) Init() tea.Cmd {
-	return nil
+	// Start loading the folders the last run left open
+	return fm.Tree.FlushCmds()
 }
 
 func (fm *FileBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -41,29 +48,38 @@ func (fm *FileBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				msg = tmsg
 	*/
 
+	case teatree.ItemSelectedMsg:
+		log.Printf("selected %s", strings.Join(tmsg.Path, "/"))
+		return fm, nil
+
+	case teatree.ReloadedMsg:
+		if tmsg.Err != nil {
+			log.Printf("reading %s: %v", fm.dir, tmsg.Err)
+		}
+
 	case tea.KeyMsg:
-		switch tmsg.String() {
-		case "r": // Refresh - it will cause the parent of the currently selected item to delete all children and re-fetch them.
-			parent := fm.Tree.ActiveItem.GetParent()
-			parent.Refresh()
-			if _, ok := parent.(*teatree.Tree); ok {
-				// If we're already at the top level, it means a refresh of the root tree
-				fm.Tree.ActiveItem = fm.Tree.Items[0]
+		if fm.Tree.FilterState() == teatree.Filtering {
+			// Let the user type anything into the filter
+			break
+		}
+		switch {
+		case key.Matches(tmsg, refreshKey): // Refresh - re-read the folder the selected item is in. Whatever is still there stays open and selected.
+			if fm.Tree.ActiveItem == nil {
+				break
+			}
+			if parent, ok := fm.Tree.ActiveItem.GetParent().(*teatree.TreeItem); ok {
+				parent.Reload()
 			} else {
-				// If we're not at the top, the simplest thing is to just activate the parent of the
-				// current item and close it for re-opening
-				fm.Tree.ActiveItem = parent.(*teatree.TreeItem)
+				fm.Tree.Reload()
 			}
 
-		case "ctrl+c", "q":
+		case key.Matches(tmsg, hiddenKey):
+			fm.files.SetShowHidden(!fm.files.ShowHidden())
+			fm.Tree.Reload()
+
+		case key.Matches(tmsg, quitKey):
 			fm.quitting = true
 			return fm, tea.Quit
-
-		case "?":
-			if fm.info != nil {
-				fm.info()
-			}
-			return fm, nil
 		}
 	}
 	_, cmd := fm.Tree.Update(msg)
@@ -74,113 +90,113 @@ func (fm *FileBrowserModel) View() string {
 	return fm.Tree.View()
 }
 
-func TextColor(ti *teatree.TreeItem) lipgloss.Style {
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")) // white
-}
-
-func FolderIcon(ti *teatree.TreeItem) string {
-	return IconFolder
-}
-
-func FolderColor(ti *teatree.TreeItem) lipgloss.Style {
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFCF00")) // yellow
-}
-
-func GoFileIcon(ti *teatree.TreeItem) string {
-	return GoTitle
-}
-
-func GoFileColor(ti *teatree.TreeItem) lipgloss.Style {
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#00FFFF")) // cyan
+// goIcon gives Go files a gopher, when there's a Nerd Font to draw it with. The other icons come
+// from the tree's glyphs, so they still work without one. Set TEATREE_GLYPHS to nerdfont, unicode
+// or ascii to pick a set.
+func goIcon(e *fstree.Entry, glyphs teatree.Glyphs) string {
+	if glyphs == teatree.NerdFontGlyphs && !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+		return GoTitle
+	}
+	return fstree.DefaultIcon(e, glyphs)
 }
 
-func FileIcon(ti *teatree.TreeItem) string {
-	return IconFile
+// fileInfo returns the info of the file an item was made from, or nil if it can't be read
+func fileInfo(ti *teatree.TreeItem) fs.FileInfo {
+	e, ok := teatree.ValueOf[*fstree.Entry](ti)
+	if !ok {
+		return nil
+	}
+	return e.Info()
 }
 
-func FileColor(ti *teatree.TreeItem) lipgloss.Style {
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7FFF7F")) // palegreen
+func fileSize(ti *teatree.TreeItem) int64 {
+	if info := fileInfo(ti); info != nil && !info.IsDir() {
+		return info.Size()
+	}
+	return -1
 }
 
-func (fm *FileBrowserModel) walk(p string, item teatree.ItemHolder) error {
-	err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			log.Printf("prevent panic by handling failure accessing a path %q: %v\n", path, err)
-			return err
-		}
-		if path == p {
-			// We don't want to render the folder we were sent. This is redundant and confusing for the user.
-			return nil
-		}
-
-		var icon func(ti *teatree.TreeItem) string
-		var iconStyle func(ti *teatree.TreeItem) lipgloss.Style
-		var labelStyle func(ti *teatree.TreeItem) lipgloss.Style
-
-		canHaveChildren := d.IsDir()
-
-		labelStyle = TextColor
-		// Default the icon and style to basic file style and icon
-		// These will be overridden by the next if statement
-		icon = FileIcon
-		iconStyle = FileColor
-
-		if d.IsDir() {
-			icon = FolderIcon
-			iconStyle = FolderColor
-		} else {
-			if strings.HasSuffix(d.Name(), ".go") {
-				icon = GoFileIcon
-				iconStyle = GoFileColor
+// columns shows the size and modification time of each file next to its name
+var columns = []teatree.Column{
+	{
+		Title: "Size",
+		Width: 8,
+		Align: lipgloss.Right,
+		Value: func(ti *teatree.TreeItem) string {
+			size := fileSize(ti)
+			switch {
+			case size < 0:
+				return ""
+			case size < 1024:
+				return fmt.Sprintf("%d B", size)
+			case size < 1024*1024:
+				return fmt.Sprintf("%.1f K", float64(size)/1024)
 			}
-		}
-
-		openFunc := func(ti *teatree.TreeItem) {
-			// This function is called when the user toggles an item that can have children. For now that only means this is a folder and we are now supposed to walk the ti's path, adding items
-			// If we have no children, then we should walk the directory.
-			// If we DO have children, they will be cached. Added a "r" refresh handler to
-			// cause a directory to be re-read, to take care of directory changes.
-			if len(ti.Children) == 0 {
-				err = fm.walk(path, ti)
+			return fmt.Sprintf("%.1f M", float64(size)/(1024*1024))
+		},
+		Less: func(a, b *teatree.TreeItem) bool {
+			return fileSize(a) < fileSize(b)
+		},
+	},
+	{
+		Title: "Modified",
+		Width: 16,
+		Value: func(ti *teatree.TreeItem) string {
+			if info := fileInfo(ti); info != nil {
+				return info.ModTime().Format("2006-01-02 15:04")
 			}
-		}
-		var children []*teatree.TreeItem
-		newitem := teatree.NewItem(d.Name(), canHaveChildren, children, icon, labelStyle, iconStyle, openFunc, nil, nil)
-		item.AddChildren(newitem)
-
-		if d.IsDir() && path != p {
-			// Do not descend into subdirectories
-			return filepath.SkipDir
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		log.Printf("error walking the path %q: %v\n", p, err)
-		return err
-	}
-	return nil
+			return ""
+		},
+	},
 }
 
 func New(dir string) tea.Model {
 	fm := &FileBrowserModel{
-		dir:  dir,
-		Tree: teatree.New().(*teatree.Tree),
+		dir:   dir,
+		files: fstree.NewDir(dir, fstree.Options{Icon: goIcon}),
+		Tree:  teatree.New().(*teatree.Tree),
 	}
-	fm.info = func() {
-		log.Print("INFO")
+	fm.Tree.Columns = columns
+	fm.Tree.Less = teatree.DirectoriesFirst(nil)
+	fm.Tree.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{refreshKey, hiddenKey, quitKey}
 	}
-	if err := fm.walk(dir, fm.Tree); err != nil {
+	fm.Tree.AdditionalFullHelpKeys = fm.Tree.AdditionalShortHelpKeys
+	if err := fm.Tree.SetProvider(context.Background(), fm.files); err != nil {
 		log.Fatal(err)
 	}
+	fm.loadState()
 	return fm
 }
 
+// stateFile is where the open folders and the cursor are kept from one run to the next
+const stateFile = "filebrowser-state.json"
+
+// loadState puts the tree back the way it was left the last time. Folders that have gone since are
+// skipped.
+func (fm *FileBrowserModel) loadState() {
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return
+	}
+	var state teatree.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("ignoring %s: %v", stateFile, err)
+		return
+	}
+	fm.Tree.RestoreState(state)
+}
+
+func (fm *FileBrowserModel) saveState() {
+	data, err := json.Marshal(fm.Tree.SaveState())
+	if err == nil {
+		err = os.WriteFile(stateFile, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("saving %s: %v", stateFile, err)
+	}
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Println("usage: filebrowser <foldername>")
@@ -197,10 +213,12 @@ func main() {
 
 	dir := os.Args[1]
 	m := New(dir)
-	p := tea.NewProgram(m)
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(m, tea.WithMouseCellMotion())
+	final, err := p.Run()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	final.(*FileBrowserModel).saveState()
 
 }
@@ -0,0 +1,165 @@
+package teatree
+
+import (
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sahilm/fuzzy"
+)
+
+// FilterState describes the current filtering state of the tree
+type FilterState int
+
+const (
+	Unfiltered    FilterState = iota // no filter set
+	Filtering                        // user is typing the filter
+	FilterApplied                    // filter is set and the user is moving through the matches
+)
+
+func (f FilterState) String() string {
+	return [...]string{
+		"unfiltered",
+		"filtering",
+		"filter applied",
+	}[f]
+}
+
+// FilterState returns the current filter state
+func (t *Tree) FilterState() FilterState {
+	return t.filterState
+}
+
+// FilterText returns what the user has typed into the filter
+func (t *Tree) FilterText() string {
+	return t.filterInput.Value()
+}
+
+// StartFilter puts the tree into filter mode, where keystrokes go to the filter input until it
+// is accepted or cleared
+func (t *Tree) StartFilter() tea.Cmd {
+	if t.filterState == Unfiltered {
+		t.filterPrevActive = t.ActiveItem
+	}
+	t.filterState = Filtering
+	t.ScrollToActive()
+	return t.filterInput.Focus()
+}
+
+// ClearFilter drops the filter and goes back to showing the whole tree. The ancestors of the
+// active item are opened, so the cursor stays on whatever the user found.
+func (t *Tree) ClearFilter() {
+	if t.ActiveItem == nil {
+		t.ActiveItem = t.filterPrevActive
+	}
+	if t.ActiveItem != nil {
+		for par, ok := t.ActiveItem.Parent.(*TreeItem); ok; par, ok = par.Parent.(*TreeItem) {
+			par.Open = true
+		}
+	}
+	t.filterState = Unfiltered
+	t.filterInput.Reset()
+	t.filterInput.Blur()
+	t.filterMatches = nil
+	t.filterShown = nil
+	t.filterPrevActive = nil
+	t.Invalidate()
+	t.ScrollToActive()
+}
+
+func (t *Tree) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, t.KeyMap.ClearFilter):
+		t.ClearFilter()
+		return nil
+	case key.Matches(msg, t.KeyMap.AcceptFilter):
+		if t.filterInput.Value() == "" {
+			t.ClearFilter()
+			return nil
+		}
+		t.filterState = FilterApplied
+		t.filterInput.Blur()
+		return nil
+	}
+
+	before := t.filterInput.Value()
+	var cmd tea.Cmd
+	t.filterInput, cmd = t.filterInput.Update(msg)
+	if t.filterInput.Value() != before {
+		t.applyFilter()
+	}
+	return cmd
+}
+
+// filterSource adapts a list of items to the fuzzy.Source interface
+type filterSource struct {
+	tree  *Tree
+	items []*TreeItem
+}
+
+func (fs filterSource) String(i int) string {
+	if fs.tree.FilterValue != nil {
+		return fs.tree.FilterValue(fs.items[i])
+	}
+	return fs.items[i].Name
+}
+
+func (fs filterSource) Len() int {
+	return len(fs.items)
+}
+
+// loadedItems returns every item that has been added to the tree, open or not. Children that
+// haven't been loaded by an OpenFunc yet can't be found.
+func loadedItems(all []*TreeItem, items []*TreeItem) []*TreeItem {
+	for _, item := range items {
+		all = append(all, item)
+		all = loadedItems(all, item.Children)
+	}
+	return all
+}
+
+// applyFilter fuzzy matches the filter text against every loaded item. The matches are shown
+// along with their ancestors, and the best match becomes the active item.
+func (t *Tree) applyFilter() {
+	value := t.filterInput.Value()
+	if value == "" {
+		t.filterMatches = nil
+		t.filterShown = nil
+		t.Invalidate()
+		t.ScrollToActive()
+		return
+	}
+
+	src := filterSource{tree: t, items: loadedItems(nil, t.Items)}
+	matches := fuzzy.FindFrom(value, src)
+
+	t.filterMatches = make(map[*TreeItem][]int, len(matches))
+	t.filterShown = make(map[*TreeItem]bool, len(matches))
+	for _, m := range matches {
+		item := src.items[m.Index]
+		t.filterMatches[item] = runeIndexes(m.Str, m.MatchedIndexes)
+		for ti := item; ti != nil && !t.filterShown[ti]; {
+			t.filterShown[ti] = true
+			par, ok := ti.Parent.(*TreeItem)
+			if !ok {
+				break
+			}
+			ti = par
+		}
+	}
+
+	if len(matches) > 0 {
+		t.ActiveItem = src.items[matches[0].Index]
+	}
+	t.Invalidate()
+	t.ScrollToActive()
+}
+
+// runeIndexes converts the byte offsets reported by fuzzy into rune offsets for highlighting
+func runeIndexes(s string, byteIndexes []int) []int {
+	runes := make([]int, len(byteIndexes))
+	for x, b := range byteIndexes {
+		runes[x] = utf8.RuneCountInString(s[:b])
+	}
+	return runes
+}
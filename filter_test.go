@@ -0,0 +1,88 @@
+package teatree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func typeText(tr *Tree, s string) {
+	for _, r := range s {
+		tr.Update(keyMsg(string(r)))
+	}
+}
+
+func TestFilterShowsMatchesWithAncestors(t *testing.T) {
+	tr := newTodoTree(10)
+
+	tr.Update(keyMsg("/"))
+	if tr.FilterState() != Filtering {
+		t.Fatalf("expected to be filtering, got %s", tr.FilterState())
+	}
+	typeText(tr, "subsub")
+	if tr.FilterText() != "subsub" {
+		t.Fatalf("expected the filter text to be typed, got %q", tr.FilterText())
+	}
+
+	// Everything is closed, but the match and its ancestors are still shown
+	want := []string{"Item 1", "Sub 1", "SubSub 1"}
+	if got := viewLines(tr)[1:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if tr.ActiveItem.Name != "SubSub 1" {
+		t.Fatalf("expected the best match to be active, got %s", tr.ActiveItem.Name)
+	}
+	if got := tr.filterMatches[tr.ActiveItem]; !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected matched characters %v", got)
+	}
+
+	// Accept the filter and move around the matches
+	tr.Update(keyMsg("enter"))
+	if tr.FilterState() != FilterApplied {
+		t.Fatalf("expected the filter to be applied, got %s", tr.FilterState())
+	}
+	tr.Update(keyMsg("k"))
+	if tr.ActiveItem.Name != "Sub 1" {
+		t.Fatalf("expected to move up to Sub 1, got %s", tr.ActiveItem.Name)
+	}
+
+	// Esc drops the filter and leaves the cursor where it was, with its ancestors opened
+	tr.Update(keyMsg("esc"))
+	if tr.FilterState() != Unfiltered {
+		t.Fatalf("expected the filter to be cleared, got %s", tr.FilterState())
+	}
+	if tr.ActiveItem.Name != "Sub 1" || !tr.Items[0].Open {
+		t.Fatalf("expected Sub 1 to stay active and visible, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestFilterNoMatches(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.SetActive(tr.Items[1])
+
+	tr.Update(keyMsg("/"))
+	typeText(tr, "zzz")
+	if got := viewLines(tr); len(got) != 1 {
+		t.Fatalf("expected only the filter input to be shown, got %v", got)
+	}
+	tr.Update(keyMsg("esc"))
+	if tr.ActiveItem != tr.Items[1] {
+		t.Fatalf("expected the original active item back, got %v", tr.ActiveItem)
+	}
+}
+
+func TestFilterValue(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.FilterValue = func(ti *TreeItem) string {
+		if ti.Name == "BB" {
+			return "needle"
+		}
+		return ti.Name
+	}
+
+	tr.Update(keyMsg("/"))
+	typeText(tr, "needle")
+	if tr.ActiveItem.Name != "BB" {
+		t.Fatalf("expected BB to match, got %s", tr.ActiveItem.Name)
+	}
+}
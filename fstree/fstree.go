@@ -0,0 +1,286 @@
+// Package fstree fills a teatree.Tree with the files in an fs.FS, or a directory on disk. The
+// directories are read as they are opened, hidden files can be shown or hidden, symlinks that
+// lead back into one of their own ancestors aren't followed, and directories that can't be read
+// show their errors inline, with the tree's retry key.
+//
+//	p := fstree.NewDir(dir, fstree.Options{})
+//	if err := tree.SetProvider(ctx, p); err != nil {
+//		...
+//	}
+package fstree
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/greenenergy/teatree"
+)
+
+// Options are how a Provider shows the files
+type Options struct {
+	ShowHidden bool                                         // Show the files whose names start with a dot, see SetShowHidden
+	Icon       func(e *Entry, glyphs teatree.Glyphs) string // The icon for an entry, DefaultIcon if nil
+	IconStyle  func(e *Entry) lipgloss.Style                // The icon's style, DefaultIconStyle if nil
+	LabelStyle func(e *Entry) lipgloss.Style                // The name's style, DefaultLabelStyle if nil
+}
+
+// Provider is a teatree.Provider that lists the files in an fs.FS. The nodes it hands out, which
+// the tree keeps in each item's Data, are *Entry.
+type Provider struct {
+	fsys       fs.FS
+	opts       Options
+	showHidden atomic.Bool
+}
+
+// New returns a Provider for the files in fsys
+func New(fsys fs.FS, opts Options) *Provider {
+	p := &Provider{fsys: fsys, opts: opts}
+	p.showHidden.Store(opts.ShowHidden)
+	return p
+}
+
+// NewDir returns a Provider for the files in the directory dir, on disk
+func NewDir(dir string, opts Options) *Provider {
+	return New(os.DirFS(dir), opts)
+}
+
+// SetShowHidden shows or hides the files whose names start with a dot. It takes effect as
+// directories are read, so call the tree's Reload to apply it to the ones already open.
+func (p *Provider) SetShowHidden(show bool) {
+	p.showHidden.Store(show)
+}
+
+// ShowHidden reports whether the files whose names start with a dot are shown
+func (p *Provider) ShowHidden() bool {
+	return p.showHidden.Load()
+}
+
+// Entry is a file or directory
+type Entry struct {
+	Path  string // Where it is in the FS, slash separated
+	Link  string // Where it points, if it's a symlink
+	Cycle bool   // It's a symlink to a directory it's inside of, so it can't be opened
+	Err   error  // Why it couldn't be looked at, such as a symlink that points nowhere
+
+	dir      bool
+	entry    fs.DirEntry
+	info     fs.FileInfo
+	infoErr  error
+	infoOnce sync.Once
+	fsys     fs.FS
+	parent   *Entry
+	real     string // Path with the symlinks resolved, or "" if that isn't known
+}
+
+// Name is the last element of the entry's path
+func (e *Entry) Name() string {
+	return path.Base(e.Path)
+}
+
+// IsDir reports whether the entry is a directory, or a symlink to one
+func (e *Entry) IsDir() bool {
+	return e.dir
+}
+
+// IsSymlink reports whether the entry is a symlink
+func (e *Entry) IsSymlink() bool {
+	return e.entry != nil && e.entry.Type()&fs.ModeSymlink != 0
+}
+
+// Info describes the entry, or what it points to if it's a symlink. It is nil if that can't be
+// read.
+func (e *Entry) Info() fs.FileInfo {
+	e.infoOnce.Do(func() {
+		if e.info != nil {
+			return
+		}
+		if e.entry == nil || e.IsSymlink() {
+			e.info, e.infoErr = fs.Stat(e.fsys, e.Path)
+		} else {
+			e.info, e.infoErr = e.entry.Info()
+		}
+		if e.infoErr != nil {
+			e.info = nil
+		}
+	})
+	return e.info
+}
+
+// Hidden reports whether the entry's name starts with a dot
+func (e *Entry) Hidden() bool {
+	return strings.HasPrefix(e.Name(), ".")
+}
+
+// readLinkFS is implemented by the file systems that can say where a symlink points. It's the
+// same as fs.ReadLinkFS, which newer versions of Go have.
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// Children lists the entries in a directory, or the top of the FS for a nil parent
+func (p *Provider) Children(ctx context.Context, parent teatree.Node) ([]teatree.Node, error) {
+	dir, _ := parent.(*Entry)
+	if dir == nil {
+		dir = &Entry{Path: ".", dir: true, fsys: p.fsys, real: "."}
+	}
+	entries, err := fs.ReadDir(p.fsys, dir.Path)
+	if err != nil {
+		return nil, err
+	}
+	showHidden := p.ShowHidden()
+	nodes := make([]teatree.Node, 0, len(entries))
+	for _, de := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e := p.entry(dir, de)
+		if e.Hidden() && !showHidden {
+			continue
+		}
+		nodes = append(nodes, e)
+	}
+	return nodes, nil
+}
+
+// entry looks at a directory entry, following it if it's a symlink
+func (p *Provider) entry(parent *Entry, de fs.DirEntry) *Entry {
+	e := &Entry{
+		Path:   path.Join(parent.Path, de.Name()),
+		dir:    de.IsDir(),
+		entry:  de,
+		fsys:   p.fsys,
+		parent: parent,
+	}
+	if parent.real != "" {
+		e.real = path.Join(parent.real, de.Name())
+	}
+	if !e.IsSymlink() {
+		return e
+	}
+
+	if rl, ok := p.fsys.(readLinkFS); ok {
+		if link, err := rl.ReadLink(e.Path); err == nil {
+			e.Link = link
+		}
+	}
+	e.real = ""
+	if e.Link != "" && !path.IsAbs(e.Link) && parent.real != "" {
+		e.real = path.Join(parent.real, e.Link)
+		if e.real == ".." || strings.HasPrefix(e.real, "../") {
+			e.real = "" // Out of the FS
+		}
+	}
+	info := e.Info()
+	if info == nil {
+		e.Err = e.infoErr
+		return e
+	}
+	e.dir = info.IsDir()
+	e.Cycle = e.dir && e.loops()
+	return e
+}
+
+// loops reports whether the entry is one of its own ancestors
+func (e *Entry) loops() bool {
+	for anc := e.parent; anc != nil; anc = anc.parent {
+		if e.real != "" && e.real == anc.real {
+			return true
+		}
+		if info, ancInfo := e.Info(), anc.Info(); info != nil && ancInfo != nil && os.SameFile(info, ancInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasChildren reports whether the entry is a directory that can be opened
+func (p *Provider) HasChildren(node teatree.Node) bool {
+	e := node.(*Entry)
+	return e.dir && !e.Cycle
+}
+
+// Key is the entry's path
+func (p *Provider) Key(node teatree.Node) string {
+	return node.(*Entry).Path
+}
+
+// Name is the entry's name
+func (p *Provider) Name(node teatree.Node) string {
+	return node.(*Entry).Name()
+}
+
+// Icon draws the entry's icon with Options.Icon
+func (p *Provider) Icon(ti *teatree.TreeItem) string {
+	e, ok := teatree.ValueOf[*Entry](ti)
+	if !ok {
+		return ""
+	}
+	var glyphs teatree.Glyphs
+	if ti.ParentTree != nil {
+		glyphs = ti.ParentTree.Glyphs
+	}
+	if p.opts.Icon != nil {
+		return p.opts.Icon(e, glyphs)
+	}
+	return DefaultIcon(e, glyphs)
+}
+
+// IconStyle styles the entry's icon with Options.IconStyle
+func (p *Provider) IconStyle(ti *teatree.TreeItem) lipgloss.Style {
+	e, ok := teatree.ValueOf[*Entry](ti)
+	switch {
+	case !ok:
+		return lipgloss.NewStyle()
+	case p.opts.IconStyle != nil:
+		return p.opts.IconStyle(e)
+	}
+	return DefaultIconStyle(e)
+}
+
+// LabelStyle styles the entry's name with Options.LabelStyle
+func (p *Provider) LabelStyle(ti *teatree.TreeItem) lipgloss.Style {
+	e, ok := teatree.ValueOf[*Entry](ti)
+	switch {
+	case !ok:
+		return lipgloss.NewStyle()
+	case p.opts.LabelStyle != nil:
+		return p.opts.LabelStyle(e)
+	}
+	return DefaultLabelStyle(e)
+}
+
+// DefaultIcon is the folder glyph for directories, and the file glyph for everything else
+func DefaultIcon(e *Entry, glyphs teatree.Glyphs) string {
+	if e.IsDir() {
+		return glyphs.Folder
+	}
+	return glyphs.File
+}
+
+// DefaultIconStyle colors directories yellow, symlinks cyan and other files pale green
+func DefaultIconStyle(e *Entry) lipgloss.Style {
+	switch {
+	case e.IsSymlink():
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")) // cyan
+	case e.IsDir():
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFCF00")) // yellow
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#7FFF7F")) // palegreen
+}
+
+// DefaultLabelStyle dims hidden files, and shows broken symlinks and symlink cycles in red
+func DefaultLabelStyle(e *Entry) lipgloss.Style {
+	switch {
+	case e.Err != nil || e.Cycle:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F")) // red
+	case e.Hidden():
+		return lipgloss.NewStyle().Faint(true)
+	}
+	return lipgloss.NewStyle()
+}
@@ -0,0 +1,199 @@
+package fstree
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/greenenergy/teatree"
+)
+
+// settle runs cmd and feeds everything it produces back to the tree, other than the spinner's
+// ticks and the tree's own reports, until there's nothing left
+func settle(tr *teatree.Tree, cmd tea.Cmd) {
+	for cmd != nil {
+		var next []tea.Cmd
+		for _, msg := range run(cmd) {
+			switch msg.(type) {
+			case spinner.TickMsg, teatree.ReloadedMsg:
+				continue
+			}
+			_, c := tr.Update(msg)
+			next = append(next, c)
+		}
+		cmd = tea.Batch(next...)
+	}
+}
+
+func run(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, run(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func names(items []*teatree.TreeItem) string {
+	var s []string
+	for _, item := range items {
+		s = append(s, item.Name)
+	}
+	return strings.Join(s, " ")
+}
+
+func child(items []*teatree.TreeItem, name string) *teatree.TreeItem {
+	for _, item := range items {
+		if item.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func newTree(t *testing.T, p *Provider) *teatree.Tree {
+	t.Helper()
+	tr := teatree.New().(*teatree.Tree)
+	tr.Height = 20
+	tr.Less = teatree.DirectoriesFirst(nil)
+	tr.SetGlyphs(teatree.ASCIIGlyphs)
+	if err := tr.SetProvider(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+func TestProvider(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":     {Data: []byte("package main")},
+		"src/.git/config": {},
+		".hidden":         {},
+		"Readme.md":       {},
+		"docs":            {Mode: fs.ModeDir},
+	}
+	p := New(fsys, Options{})
+	tr := newTree(t, p)
+	if got := names(tr.Items); got != "docs src Readme.md" {
+		t.Fatalf("expected the top level without hidden files, got %s", got)
+	}
+	docs, readme := child(tr.Items, "docs"), child(tr.Items, "Readme.md")
+	if docs.Icon() != teatree.ASCIIGlyphs.Folder || readme.Icon() != teatree.ASCIIGlyphs.File {
+		t.Fatalf("expected folder and file icons, got %q and %q", docs.Icon(), readme.Icon())
+	}
+
+	src := child(tr.Items, "src")
+	src.ToggleChildren()
+	settle(tr, tr.FlushCmds())
+	if got := names(src.Children); got != "main.go" {
+		t.Fatalf("expected the children of src, got %s", got)
+	}
+	main := child(src.Children, "main.go")
+	if e, ok := teatree.ValueOf[*Entry](main); !ok || e.Path != "src/main.go" || e.Info().Size() != 12 {
+		t.Fatal("expected the item to carry its entry")
+	}
+
+	// Showing the hidden files keeps what's open
+	p.SetShowHidden(true)
+	tr.Reload()
+	settle(tr, tr.FlushCmds())
+	if got := names(tr.Items); got != "docs src .hidden Readme.md" {
+		t.Fatalf("expected the hidden file to be shown, got %s", got)
+	}
+	if child(tr.Items, "src") != src || !src.Open || names(src.Children) != ".git main.go" {
+		t.Fatalf("expected src to stay open with .git shown, got %s", names(src.Children))
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b/file":  {},
+		"a/b/loop":  {Mode: fs.ModeSymlink, Data: []byte("..")},
+		"a/link":    {Mode: fs.ModeSymlink, Data: []byte("b")},
+		"a/broken":  {Mode: fs.ModeSymlink, Data: []byte("nowhere")},
+		"a/b/top":   {Mode: fs.ModeSymlink, Data: []byte("../..")},
+		"a/b/other": {Mode: fs.ModeSymlink, Data: []byte("../../c")},
+		"c/d":       {},
+	}
+	if _, ok := fs.FS(fsys).(readLinkFS); !ok {
+		t.Skip("this version of fstest.MapFS doesn't have symlinks")
+	}
+	p := New(fsys, Options{})
+	ctx := context.Background()
+	entries := func(dir *Entry) map[string]*Entry {
+		t.Helper()
+		nodes, err := p.Children(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := map[string]*Entry{}
+		for _, node := range nodes {
+			byName[p.Name(node)] = node.(*Entry)
+		}
+		return byName
+	}
+	a := entries(nil)["a"]
+	inA := entries(a)
+	if link := inA["link"]; !link.IsSymlink() || !p.HasChildren(link) || link.Cycle || link.Link != "b" {
+		t.Fatal("expected a/link to be a symlink to a directory that can be opened")
+	}
+	if broken := inA["broken"]; broken.Err == nil || p.HasChildren(broken) {
+		t.Fatal("expected a/broken to have an error")
+	}
+	inB := entries(inA["b"])
+	for _, name := range []string{"loop", "top"} {
+		if e := inB[name]; !e.Cycle || p.HasChildren(e) {
+			t.Fatalf("expected a/b/%s to be a cycle", name)
+		}
+	}
+	if other := inB["other"]; other.Cycle || !p.HasChildren(other) {
+		t.Fatal("expected a/b/other, which goes somewhere else, not to be a cycle")
+	}
+	if e := entries(inA["link"])["loop"]; !e.Cycle {
+		t.Fatal("expected a/link/loop, which is a/b/loop, to be a cycle too")
+	}
+
+	// So a search through everything finishes
+	tr := newTree(t, p)
+	paths, err := tr.Search(ctx, func(teatree.Node) bool { return true })
+	if err != nil || len(paths) == 0 || len(paths) > 20 {
+		t.Fatalf("expected the search to stop at the cycles, got %d paths and %v", len(paths), err)
+	}
+}
+
+// deniedFS can't read one of its directories
+type deniedFS struct {
+	fstest.MapFS
+	denied string
+}
+
+func (d deniedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == d.denied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.MapFS.ReadDir(name)
+}
+
+func TestPermissionError(t *testing.T) {
+	fsys := deniedFS{MapFS: fstest.MapFS{"secret/key": {}, "public/doc": {}}, denied: "secret"}
+	tr := newTree(t, New(fsys, Options{}))
+	secret := child(tr.Items, "secret")
+	secret.ToggleChildren()
+	settle(tr, tr.FlushCmds())
+	if !errors.Is(secret.LoadError(), fs.ErrPermission) {
+		t.Fatalf("expected a permission error, got %v", secret.LoadError())
+	}
+	if !strings.Contains(tr.View(), "permission denied") {
+		t.Fatal("expected the error to be shown under the directory")
+	}
+}
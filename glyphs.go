@@ -0,0 +1,150 @@
+package teatree
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Glyphs is a set of symbols the tree, and the icons of its items, are drawn with. Not every
+// terminal has a Nerd Font, so there are plainer sets to fall back on.
+type Glyphs struct {
+	Open   string // in front of an open item
+	Closed string // in front of a closed item that can have children
+	Folder string // an icon for items that can have children, for hosts that want one
+	File   string // an icon for items that can't
+
+	// The pieces of the indent guides, see Tree.IndentGuides
+	Vertical   string // passes a level by, on its way to a later sibling
+	Branch     string // leads to a child with more siblings after it
+	Last       string // leads to the last child
+	Horizontal string // fills the rest of the level after Branch or Last
+
+	// Shown next to the title of the column the tree is sorted by
+	SortAscending  string
+	SortDescending string
+}
+
+// NerdFontGlyphs are the material design icons from a Nerd Font, see https://www.nerdfonts.com
+var NerdFontGlyphs = Glyphs{
+	Open:   ChevronDown,
+	Closed: ChevronRight,
+	Folder: "\U000F024B",
+	File:   "\U000F0214",
+
+	Vertical:   "│",
+	Branch:     "├",
+	Last:       "└",
+	Horizontal: "─",
+
+	SortAscending:  "↑",
+	SortDescending: "↓",
+}
+
+// UnicodeGlyphs only need a font with the usual box drawing and geometric shapes
+var UnicodeGlyphs = Glyphs{
+	Open:   "▾",
+	Closed: "▸",
+	Folder: "■",
+	File:   "□",
+
+	Vertical:   "│",
+	Branch:     "├",
+	Last:       "└",
+	Horizontal: "─",
+
+	SortAscending:  "↑",
+	SortDescending: "↓",
+}
+
+// ASCIIGlyphs work everywhere, even on a serial console
+var ASCIIGlyphs = Glyphs{
+	Open:   "-",
+	Closed: "+",
+	Folder: "/",
+	File:   "-",
+
+	Vertical:   "|",
+	Branch:     "|",
+	Last:       "`",
+	Horizontal: "-",
+
+	SortAscending:  "^",
+	SortDescending: "v",
+}
+
+// GlyphsEnv is the environment variable DetectGlyphs looks at first. It can be set to "nerdfont",
+// "unicode" or "ascii".
+const GlyphsEnv = "TEATREE_GLYPHS"
+
+// DetectGlyphs picks the glyphs a new tree starts with. GlyphsEnv wins if it is set. Otherwise
+// terminals that can't show Unicode get ASCIIGlyphs, and everything else gets NerdFontGlyphs,
+// since there's no way to ask a terminal what font it is using.
+func DetectGlyphs() Glyphs {
+	if g, ok := GlyphsNamed(os.Getenv(GlyphsEnv)); ok {
+		return g
+	}
+	if os.Getenv("TERM") == "dumb" || os.Getenv("TERM") == "linux" || !utf8Locale() {
+		return ASCIIGlyphs
+	}
+	return NerdFontGlyphs
+}
+
+// GlyphsNamed returns the glyph set with the given name, as used by GlyphsEnv
+func GlyphsNamed(name string) (Glyphs, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "nerdfont", "nerd":
+		return NerdFontGlyphs, true
+	case "unicode":
+		return UnicodeGlyphs, true
+	case "ascii":
+		return ASCIIGlyphs, true
+	}
+	return Glyphs{}, false
+}
+
+// utf8Locale reports whether the locale, which is the first of LC_ALL, LC_CTYPE and LANG to be
+// set, uses UTF-8
+func utf8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// SetGlyphs switches the tree over to another glyph set, including the chevrons
+func (t *Tree) SetGlyphs(g Glyphs) {
+	t.Glyphs = g
+	t.OpenChildrenSymbol = g.Open
+	t.ClosedChildrenSymbol = g.Closed
+}
+
+// chevron returns the symbol drawn in front of an item, padded so that open, closed and childless
+// items all line up
+func (t *Tree) chevron(canHaveChildren, expanded bool) string {
+	w := t.chevronWidth()
+	if !canHaveChildren {
+		return strings.Repeat(" ", w)
+	}
+	s := t.ClosedChildrenSymbol
+	if expanded {
+		s = t.OpenChildrenSymbol
+	}
+	return t.Styles.Chevron.Render(s + strings.Repeat(" ", w-lipgloss.Width(s)))
+}
+
+// chevronWidth is the number of columns the chevrons take up
+func (t *Tree) chevronWidth() int {
+	w := lipgloss.Width(t.OpenChildrenSymbol)
+	if c := lipgloss.Width(t.ClosedChildrenSymbol); c > w {
+		w = c
+	}
+	if w == 0 {
+		return 1
+	}
+	return w
+}
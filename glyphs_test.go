@@ -0,0 +1,57 @@
+package teatree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectGlyphs(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	t.Setenv(GlyphsEnv, "")
+	if DetectGlyphs() != NerdFontGlyphs {
+		t.Fatal("expected a UTF-8 terminal to get the Nerd Font glyphs")
+	}
+
+	t.Setenv("LANG", "C")
+	if DetectGlyphs() != ASCIIGlyphs {
+		t.Fatal("expected a terminal without UTF-8 to get the ASCII glyphs")
+	}
+
+	t.Setenv(GlyphsEnv, "Unicode")
+	if DetectGlyphs() != UnicodeGlyphs {
+		t.Fatalf("expected %s to win", GlyphsEnv)
+	}
+	if tr := New().(*Tree); tr.OpenChildrenSymbol != "▾" || tr.ClosedChildrenSymbol != "▸" {
+		t.Fatalf("expected a new tree to use the detected glyphs, got %q and %q", tr.OpenChildrenSymbol, tr.ClosedChildrenSymbol)
+	}
+}
+
+func TestChevronSymbols(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.SetGlyphs(ASCIIGlyphs)
+	find(tr, "Item 1").ToggleChildren()
+
+	lines := strings.Split(tr.View(), "\n")
+	for x, want := range []string{"- Item 1", "  + Sub 1", "+ Item 2"} {
+		if got := strings.TrimRight(lines[x], " "); got != want {
+			t.Fatalf("line %d: expected %q, got %q", x, want, got)
+		}
+	}
+
+	// Symbols of different widths are padded, so the labels still line up
+	tr.OpenChildrenSymbol = "[-]"
+	tr.ClosedChildrenSymbol = "[+]"
+	lines = strings.Split(tr.View(), "\n")
+	if got := strings.TrimRight(lines[1], " "); got != "  [+] Sub 1" {
+		t.Fatalf("expected the wider symbol, got %q", got)
+	}
+
+	// and clicks on any part of the symbol still toggle
+	tr.Update(click(4, 1))
+	if !find(tr, "Item 1", "Sub 1").Open {
+		t.Fatal("expected a click on the wide chevron to open Sub 1")
+	}
+}
@@ -0,0 +1,55 @@
+package teatree
+
+import "strings"
+
+// DefaultIndentWidth is how many columns each level of the tree is indented by, if
+// Tree.IndentWidth isn't set
+const DefaultIndentWidth = 2
+
+func (t *Tree) indentWidth() int {
+	if t.IndentWidth > 0 {
+		return t.IndentWidth
+	}
+	return DefaultIndentWidth
+}
+
+// guides returns what's drawn to the left of a row's chevron. Normally that's just blanks, but
+// with IndentGuides each level gets a line joining the children to their parent, like this:
+//
+//	▾ Item 1
+//	├─▾ Sub 1
+//	│ └─  SubSub 1
+//	└─  Sub 2
+//
+// Every row works its guides out from its own ancestors, rather than from the rows above it, so
+// they are right even when the view starts partway through a subtree.
+func (ti *TreeItem) guides() string {
+	tree := ti.ParentTree
+	w := tree.indentWidth()
+	if !tree.IndentGuides || ti.rowDepth == 0 {
+		return strings.Repeat(" ", ti.rowDepth*w)
+	}
+
+	// Walk up from the row, filling in each level's guide from the right
+	g := tree.Glyphs
+	levels := make([]string, ti.rowDepth)
+	item := ti
+	for level := ti.rowDepth - 1; level >= 0; level-- {
+		switch {
+		case item == ti && item.rowLast:
+			levels[level] = g.Last + strings.Repeat(g.Horizontal, w-1)
+		case item == ti:
+			levels[level] = g.Branch + strings.Repeat(g.Horizontal, w-1)
+		case item.rowLast:
+			levels[level] = strings.Repeat(" ", w)
+		default:
+			levels[level] = g.Vertical + strings.Repeat(" ", w-1)
+		}
+		par, ok := item.Parent.(*TreeItem)
+		if !ok {
+			break
+		}
+		item = par
+	}
+	return tree.Styles.IndentGuide.Render(strings.Join(levels, ""))
+}
@@ -0,0 +1,65 @@
+package teatree
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderedLines returns each line of the view, without the padding on the right
+func renderedLines(tr *Tree) []string {
+	lines := strings.Split(tr.View(), "\n")
+	for x, line := range lines {
+		lines[x] = strings.TrimRight(line, " ")
+	}
+	return lines
+}
+
+func TestIndentGuides(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.SetGlyphs(UnicodeGlyphs)
+	tr.IndentGuides = true
+	find(tr, "Item 1").AddChildren(item("Sub 2"))
+	openAll(tr.Items)
+
+	want := []string{
+		"▾ Item 1",
+		"├─▾ Sub 1",
+		"│ └─  SubSub 1",
+		"└─  Sub 2",
+		"▾ Item 2",
+		"├─  AA",
+		"└─  BB",
+		"▾ Item 3",
+		"└─  CC",
+	}
+	if got := renderedLines(tr); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	// Starting the view partway through a subtree still shows the lines leading past it
+	tr.Height = 2
+	tr.SetActive(find(tr, "Item 1", "Sub 1", "SubSub 1"))
+	tr.ScrollUp(tr.viewtop)
+	tr.ScrollDown(2)
+	if got := renderedLines(tr); got[0] != "│ └─  SubSub 1" || got[1] != "└─  Sub 2" {
+		t.Fatalf("expected the guides to carry on past the top of the view, got %q", got)
+	}
+}
+
+func TestIndentWidth(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.SetGlyphs(ASCIIGlyphs)
+	tr.IndentGuides = true
+	tr.IndentWidth = 4
+	openAll(tr.Items)
+
+	if got := renderedLines(tr)[2]; got != "    `---  SubSub 1" {
+		t.Fatalf("expected a 4 column indent, got %q", got)
+	}
+
+	// Sub 1's chevron has moved over to column 4
+	tr.Update(click(4, 1))
+	if find(tr, "Item 1", "Sub 1").Open {
+		t.Fatal("expected a click on the chevron to close Sub 1")
+	}
+}
@@ -0,0 +1,93 @@
+package teatree
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Make sure both the KeyMap and the Tree can be rendered by a bubbles help.Model
+var (
+	_ help.KeyMap = KeyMap{}
+	_ help.KeyMap = &Tree{}
+)
+
+// ShortHelp returns the most important bindings, for a one line help view
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Open, k.Back, k.Select, k.Filter, k.Help}
+}
+
+// FullHelp returns every binding, grouped into columns
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.GoToTop, k.GoToLast},
+		{k.Open, k.Back, k.Space, k.Select, k.Retry, k.Sort},
+		{k.MarkUp, k.MarkDown, k.MarkChildren},
+		{k.Filter, k.ClearFilter, k.AcceptFilter, k.Help},
+	}
+}
+
+// ShortHelp returns the tree's short help, plus any bindings the host has added with
+// AdditionalShortHelpKeys
+func (t *Tree) ShortHelp() []key.Binding {
+	kb := t.KeyMap.ShortHelp()
+	if t.AdditionalShortHelpKeys != nil {
+		kb = append(kb, t.AdditionalShortHelpKeys()...)
+	}
+	return kb
+}
+
+// FullHelp returns the bindings that do something in the tree's current mode, plus a column for
+// any bindings the host has added with AdditionalFullHelpKeys
+func (t *Tree) FullHelp() [][]key.Binding {
+	var kb [][]key.Binding
+	for x, column := range t.KeyMap.FullHelp() {
+		if x == 2 && !t.MultiSelect {
+			// The marking keys only work in a MultiSelect tree
+			continue
+		}
+		if len(t.Columns) == 0 {
+			// The sort key only works in a tree with columns
+			column = without(column, t.KeyMap.Sort)
+		}
+		kb = append(kb, column)
+	}
+	if t.AdditionalFullHelpKeys != nil {
+		kb = append(kb, t.AdditionalFullHelpKeys())
+	}
+	return kb
+}
+
+// without returns the bindings, leaving out drop
+func without(bindings []key.Binding, drop key.Binding) []key.Binding {
+	var kept []key.Binding
+	for _, b := range bindings {
+		if !slices.Equal(b.Keys(), drop.Keys()) || b.Help() != drop.Help() {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+// ToggleHelp shows or hides the built-in help overlay
+func (t *Tree) ToggleHelp() {
+	t.showingHelp = !t.showingHelp
+}
+
+// ShowingHelp reports whether the help overlay is covering the tree
+func (t *Tree) ShowingHelp() bool {
+	return t.showingHelp
+}
+
+// helpOverlay renders the full help in place of the rows
+func (t *Tree) helpOverlay() string {
+	t.helpView.Width = t.innerWidth()
+	t.helpView.ShowAll = true
+	s := t.helpView.View(t)
+	if h := t.innerHeight(); h > 0 {
+		s = lipgloss.NewStyle().MaxHeight(h).Render(s)
+	}
+	return t.frame(s)
+}
@@ -0,0 +1,65 @@
+package teatree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestHelpKeyMap(t *testing.T) {
+	km := DefaultKeyMap()
+	h := help.New()
+	if s := h.View(km); !strings.Contains(s, "filter") {
+		t.Fatalf("expected the short help to mention filter, got %q", s)
+	}
+
+	// Disabled bindings drop out of the help
+	km.Filter.SetEnabled(false)
+	if s := h.View(km); strings.Contains(s, "filter") {
+		t.Fatalf("expected the disabled filter binding to be left out, got %q", s)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	tr := newTodoTree(20)
+	tr.Width = 80
+	refresh := key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh"))
+	tr.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{refresh}
+	}
+
+	tr.Update(keyMsg("?"))
+	if !tr.ShowingHelp() {
+		t.Fatal("expected ? to show the help")
+	}
+	view := tr.View()
+	for _, want := range []string{"page down", "filter", "refresh"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected the help to mention %q, got\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "mark up") {
+		t.Fatal("expected the marking keys to be left out when not MultiSelect")
+	}
+	if strings.Contains(view, "sort") {
+		t.Fatal("expected the sort key to be left out without Columns")
+	}
+
+	// Keys don't reach the tree while the help is up
+	tr.Update(keyMsg("j"))
+	if tr.ActiveItem != tr.Items[0] {
+		t.Fatalf("expected the cursor not to move, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(keyMsg("?"))
+	if tr.ShowingHelp() || !strings.Contains(tr.View(), "Item 1") {
+		t.Fatal("expected ? to hide the help again")
+	}
+
+	tr.HelpOverlay = false
+	tr.Update(keyMsg("?"))
+	if tr.ShowingHelp() {
+		t.Fatal("expected ? to do nothing when the overlay is turned off")
+	}
+}
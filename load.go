@@ -0,0 +1,288 @@
+package teatree
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChildrenLoadedMsg delivers the result of an item's LoadFunc. If Err is set, an error row is
+// shown under the item instead of children, and the user can retry with the Retry key.
+type ChildrenLoadedMsg struct {
+	Item     *TreeItem
+	Children []*TreeItem
+	Err      error
+	Total    int // How many children the item has in all, when they are loaded a page at a time. Zero if they're all here.
+	gen      int // which load this is, so results that were overtaken by a refresh are dropped
+}
+
+// AsyncLoader adapts a blocking function that lists an item's children into a LoadFunc. The
+// function is run in a tea.Cmd, so it doesn't hold up the UI. It shouldn't change the item.
+func AsyncLoader(load func(*TreeItem) ([]*TreeItem, error)) func(*TreeItem) tea.Cmd {
+	return func(ti *TreeItem) tea.Cmd {
+		return func() tea.Msg {
+			children, err := load(ti)
+			return ChildrenLoadedMsg{Item: ti, Children: children, Err: err}
+		}
+	}
+}
+
+// DefaultChildPageSize is how many children a PagedLoader fetches at a time, unless the tree's
+// ChildPageSize says otherwise
+const DefaultChildPageSize = 500
+
+// PagedLoader adapts a function that lists a page of an item's children into a LoadFunc, for
+// items with too many children to load all at once. The function returns up to limit children
+// starting at offset, along with how many children there are in all. Opening the item loads the
+// first page, and a "load more" row after the children fetches the next one when it's activated.
+func PagedLoader(load func(ti *TreeItem, offset, limit int) (children []*TreeItem, total int, err error)) func(*TreeItem) tea.Cmd {
+	return func(ti *TreeItem) tea.Cmd {
+		offset := len(ti.Children)
+		limit := DefaultChildPageSize
+		if ti.ParentTree != nil && ti.ParentTree.ChildPageSize > 0 {
+			limit = ti.ParentTree.ChildPageSize
+		}
+		if ti.reloading {
+			// Read everything that was paged in, from the start
+			limit = max(limit, offset)
+			offset = 0
+		}
+		return func() tea.Msg {
+			children, total, err := load(ti, offset, limit)
+			return ChildrenLoadedMsg{Item: ti, Children: children, Total: total, Err: err}
+		}
+	}
+}
+
+// rowStatus marks the rows that stand in for children that aren't there yet
+type rowStatus int
+
+const (
+	statusNone rowStatus = iota
+	statusLoading
+	statusError
+	statusMore // there are more children to page in
+)
+
+// queue holds on to a command started outside of Update, so that Update can return it
+func (t *Tree) queue(cmd tea.Cmd) {
+	if cmd != nil {
+		t.pending = append(t.pending, cmd)
+	}
+}
+
+// FlushCmds returns the commands for work the tree started outside of Update, such as the
+// background loads kicked off by opening an item with a LoadFunc. Update returns these itself,
+// so this is only needed when the host opens items directly, for example with Reveal.
+func (t *Tree) FlushCmds() tea.Cmd {
+	if len(t.pending) == 0 {
+		return nil
+	}
+	cmd := tea.Batch(t.pending...)
+	t.pending = nil
+	return cmd
+}
+
+// startLoad runs the item's LoadFunc, showing a loading row until the children arrive
+func (t *Tree) startLoad(ti *TreeItem) {
+	if ti.loading {
+		return
+	}
+	cmd := ti.LoadFunc(ti)
+	if cmd == nil {
+		// Nothing to wait for
+		ti.loaded = true
+		return
+	}
+
+	ti.loading = true
+	ti.loadErr = nil
+	ti.loadGen++
+	ti.setStatus(statusLoading)
+	gen := ti.loadGen
+	t.queue(func() tea.Msg {
+		msg := cmd()
+		if loaded, ok := msg.(ChildrenLoadedMsg); ok {
+			loaded.gen = gen
+			return loaded
+		}
+		return msg
+	})
+
+	t.loadsInFlight++
+	if !t.spinning {
+		t.spinning = true
+		t.queue(t.spinner.Tick)
+	}
+}
+
+// cancelLoad forgets about a load that is running, so its result will be dropped
+func (t *Tree) cancelLoad(ti *TreeItem) {
+	if ti.loading {
+		ti.loading = false
+		t.loadsInFlight--
+	}
+	ti.reloading = false
+	if ti.loadCancel != nil {
+		ti.loadCancel()
+		ti.loadCancel = nil
+	}
+	ti.loadErr = nil
+	ti.remaining = 0
+	ti.statusRow = nil
+	ti.invalidate()
+}
+
+func (t *Tree) childrenLoaded(msg ChildrenLoadedMsg) {
+	ti := msg.Item
+	if !ti.loading || msg.gen != ti.loadGen {
+		return
+	}
+	ti.loading = false
+	ti.loadCancel = nil
+	t.loadsInFlight--
+	if msg.Err != nil {
+		// A retry will still be a reload
+		ti.loadErr = msg.Err
+		ti.setStatus(statusError)
+		t.ScrollToActive()
+		return
+	}
+
+	// If the user asked for more children, the cursor moves onto the first of them
+	onStatus := ti.statusRow != nil && t.ActiveItem == ti.statusRow
+	reloading := ti.reloading
+	ti.reloading = false
+	ti.loaded = true
+	already := len(ti.Children)
+	if reloading {
+		already = 0
+	}
+	ti.remaining = msg.Total - already - len(msg.Children)
+	if ti.remaining > 0 {
+		ti.setStatus(statusMore)
+	} else {
+		ti.remaining = 0
+		ti.statusRow = nil
+		ti.invalidate()
+	}
+	if reloading {
+		ti.ReloadWith(msg.Children)
+		if onStatus && ti.statusRow == nil {
+			// The "load more" row has gone, as there's nothing more to load
+			t.SetActive(ti)
+		}
+		return
+	}
+	ti.AddChildren(msg.Children...)
+	if onStatus && len(msg.Children) > 0 {
+		t.SetActive(msg.Children[0])
+	}
+}
+
+// LoadMore fetches the next page of children for an item with a PagedLoader. It can be given
+// either the item, or its "load more" row.
+func (t *Tree) LoadMore(ti *TreeItem) {
+	if ti.placeholder != statusNone {
+		ti = ti.Parent.(*TreeItem)
+	}
+	if ti.remaining > 0 && ti.LoadFunc != nil {
+		t.startLoad(ti)
+	}
+}
+
+// Retry loads the children of the active item again, if its LoadFunc failed
+func (t *Tree) Retry() {
+	ti := t.ActiveItem
+	if ti != nil && ti.placeholder != statusNone {
+		ti = ti.Parent.(*TreeItem)
+	}
+	if ti == nil || ti.loadErr == nil {
+		return
+	}
+	t.startLoad(ti)
+}
+
+// Remaining returns how many of the item's children haven't been paged in yet
+func (ti *TreeItem) Remaining() int {
+	return ti.remaining
+}
+
+// LoadError returns the error from the last time the item's LoadFunc ran, if it failed
+func (ti *TreeItem) LoadError() error {
+	return ti.loadErr
+}
+
+// Loading reports whether the item's LoadFunc is running
+func (ti *TreeItem) Loading() bool {
+	return ti.loading
+}
+
+func (t *Tree) spin(msg spinner.TickMsg) tea.Cmd {
+	if msg.ID != t.spinner.ID() {
+		return nil
+	}
+	if t.loadsInFlight == 0 {
+		t.spinning = false
+		return nil
+	}
+	var cmd tea.Cmd
+	t.spinner, cmd = t.spinner.Update(msg)
+	return cmd
+}
+
+// setStatus shows a loading, error or "load more" row after the item's children. The same row is
+// reused, so if the cursor is on it, it stays there.
+func (ti *TreeItem) setStatus(status rowStatus) {
+	if ti.statusRow == nil {
+		ti.statusRow = &TreeItem{
+			Parent:     ti,
+			ParentTree: ti.ParentTree,
+		}
+	}
+	ti.statusRow.placeholder = status
+	ti.invalidate()
+}
+
+// selectable reports whether the cursor can stop on this row. Loading and error rows are skipped,
+// but "load more" rows can be activated.
+func (ti *TreeItem) selectable() bool {
+	return ti.placeholder == statusNone || ti.placeholder == statusMore
+}
+
+// renderStatusRow draws the loading, error or "load more" row that follows the parent's children
+func (ti *TreeItem) renderStatusRow() string {
+	tree := ti.ParentTree
+	par := ti.Parent.(*TreeItem)
+	pre_s := ti.guides() + tree.chevron(false, false)
+	switch ti.placeholder {
+	case statusLoading:
+		return pre_s + tree.Styles.Disabled.Render(tree.spinner.View()+" loading…")
+	case statusMore:
+		style := tree.Styles.Disabled
+		if tree.ActiveItem == ti {
+			style = tree.cursorStyle()
+		}
+		next := DefaultChildPageSize
+		if tree.ChildPageSize > 0 {
+			next = tree.ChildPageSize
+		}
+		if next > par.remaining {
+			next = par.remaining
+		}
+		return pre_s + style.Render(fmt.Sprintf("… load %s more (%s remaining)", commas(next), commas(par.remaining)))
+	}
+	return pre_s + tree.Styles.Error.Render("✗ "+par.loadErr.Error()) +
+		tree.Styles.Disabled.Render(" ("+tree.KeyMap.Retry.Help().Key+" to retry)")
+}
+
+// commas formats a count with thousands separators, like 12,345
+func commas(n int) string {
+	s := strconv.Itoa(n)
+	for x := len(s) - 3; x > 0; x -= 3 {
+		s = s[:x] + "," + s[x:]
+	}
+	return s
+}
@@ -0,0 +1,253 @@
+package teatree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runCmd runs a command like bubbletea would, returning every message it produces
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func loadedMsg(msgs []tea.Msg) (ChildrenLoadedMsg, bool) {
+	for _, msg := range msgs {
+		if loaded, ok := msg.(ChildrenLoadedMsg); ok {
+			return loaded, true
+		}
+	}
+	return ChildrenLoadedMsg{}, false
+}
+
+func TestAsyncLoad(t *testing.T) {
+	tr := newTodoTree(10)
+	calls := 0
+	slow := NewItem("slow", true, nil, nil, nil, nil, nil, nil, nil)
+	slow.LoadFunc = AsyncLoader(func(ti *TreeItem) ([]*TreeItem, error) {
+		calls++
+		return []*TreeItem{item("one"), item("two")}, nil
+	})
+	tr.AddChildren(slow)
+	tr.SetActive(slow)
+
+	_, cmd := tr.Update(keyMsg("l"))
+	if calls != 0 {
+		t.Fatal("expected the loader not to run inside Update")
+	}
+	if !slow.Loading() {
+		t.Fatal("expected the item to be loading")
+	}
+	if got := viewLines(tr); !strings.HasSuffix(got[len(got)-1], "loading…") {
+		t.Fatalf("expected a loading row, got %v", got)
+	}
+
+	// The loading row can't be selected
+	tr.Update(keyMsg("j"))
+	if tr.ActiveItem != slow {
+		t.Fatalf("expected to stay on slow, got %v", tr.ActiveItem)
+	}
+
+	msgs := runCmd(cmd)
+	loaded, ok := loadedMsg(msgs)
+	if !ok {
+		t.Fatalf("expected a ChildrenLoadedMsg, got %#v", msgs)
+	}
+	tr.Update(loaded)
+	if slow.Loading() || !reflect.DeepEqual(names(slow.Children), []string{"one", "two"}) {
+		t.Fatalf("expected the children to be added, got %v", names(slow.Children))
+	}
+
+	// Opening it again doesn't reload
+	slow.ToggleChildren()
+	slow.ToggleChildren()
+	if calls != 1 || tr.FlushCmds() != nil {
+		t.Fatalf("expected the children to be cached, loaded %d times", calls)
+	}
+}
+
+func TestAsyncLoadErrorAndRetry(t *testing.T) {
+	tr := newTodoTree(10)
+	fail := true
+	bad := NewItem("bad", true, nil, nil, nil, nil, nil, nil, nil)
+	bad.LoadFunc = AsyncLoader(func(ti *TreeItem) ([]*TreeItem, error) {
+		if fail {
+			return nil, errors.New("permission denied")
+		}
+		return []*TreeItem{item("ok")}, nil
+	})
+	tr.AddChildren(bad)
+	tr.SetActive(bad)
+
+	_, cmd := tr.Update(keyMsg("l"))
+	loaded, _ := loadedMsg(runCmd(cmd))
+	tr.Update(loaded)
+	if bad.LoadError() == nil {
+		t.Fatal("expected the load to fail")
+	}
+	if got := viewLines(tr); !strings.Contains(got[len(got)-1], "permission denied") {
+		t.Fatalf("expected an error row, got %v", got)
+	}
+
+	fail = false
+	_, cmd = tr.Update(keyMsg("R"))
+	loaded, ok := loadedMsg(runCmd(cmd))
+	if !ok {
+		t.Fatal("expected retry to load again")
+	}
+	tr.Update(loaded)
+	if bad.LoadError() != nil || len(bad.Children) != 1 {
+		t.Fatalf("expected the retry to succeed, got %v", bad.LoadError())
+	}
+}
+
+func TestAsyncLoadOvertakenByRefresh(t *testing.T) {
+	tr := newTodoTree(10)
+	batch := 0
+	slow := NewItem("slow", true, nil, nil, nil, nil, nil, nil, nil)
+	slow.LoadFunc = AsyncLoader(func(ti *TreeItem) ([]*TreeItem, error) {
+		batch++
+		return []*TreeItem{item(strings.Repeat("x", batch))}, nil
+	})
+	tr.AddChildren(slow)
+
+	slow.ToggleChildren()
+	first := tr.FlushCmds()
+	slow.Refresh()
+	slow.ToggleChildren()
+	second := tr.FlushCmds()
+
+	stale, _ := loadedMsg(runCmd(first))
+	fresh, _ := loadedMsg(runCmd(second))
+	tr.Update(stale)
+	if len(slow.Children) != 0 {
+		t.Fatal("expected the stale result to be dropped")
+	}
+	tr.Update(fresh)
+	if !reflect.DeepEqual(names(slow.Children), []string{"xx"}) {
+		t.Fatalf("expected the second load's children, got %v", names(slow.Children))
+	}
+}
+
+func TestSpinnerStopsWhenIdle(t *testing.T) {
+	tr := newTodoTree(10)
+	slow := NewItem("slow", true, nil, nil, nil, nil, nil, nil, nil)
+	slow.LoadFunc = AsyncLoader(func(ti *TreeItem) ([]*TreeItem, error) {
+		return nil, nil
+	})
+	tr.AddChildren(slow)
+	slow.ToggleChildren()
+
+	var tick spinner.TickMsg
+	var loaded ChildrenLoadedMsg
+	for _, msg := range runCmd(tr.FlushCmds()) {
+		switch msg := msg.(type) {
+		case spinner.TickMsg:
+			tick = msg
+		case ChildrenLoadedMsg:
+			loaded = msg
+		}
+	}
+	if _, cmd := tr.Update(tick); cmd == nil {
+		t.Fatal("expected the spinner to keep ticking while loading")
+	}
+	tr.Update(loaded)
+	if _, cmd := tr.Update(tick); cmd != nil {
+		t.Fatal("expected the spinner to stop once nothing is loading")
+	}
+}
+
+func newPagedItem(total int, offsets *[]int) *TreeItem {
+	bucket := NewItem("bucket", true, nil, nil, nil, nil, nil, nil, nil)
+	bucket.LoadFunc = PagedLoader(func(ti *TreeItem, offset, limit int) ([]*TreeItem, int, error) {
+		*offsets = append(*offsets, offset)
+		var children []*TreeItem
+		for x := offset; x < offset+limit && x < total; x++ {
+			children = append(children, item(fmt.Sprintf("obj%d", x)))
+		}
+		return children, total, nil
+	})
+	return bucket
+}
+
+// deliver runs the tree's pending commands and feeds the loaded children back to it
+func deliver(t *testing.T, tr *Tree, cmd tea.Cmd) {
+	t.Helper()
+	loaded, ok := loadedMsg(runCmd(cmd))
+	if !ok {
+		t.Fatal("expected a ChildrenLoadedMsg")
+	}
+	tr.Update(loaded)
+}
+
+func TestPagedLoad(t *testing.T) {
+	tr := newTodoTree(20)
+	tr.ChildPageSize = 2
+	var offsets []int
+	bucket := newPagedItem(12345, &offsets)
+	tr.AddChildren(bucket)
+	tr.SetActive(bucket)
+
+	_, cmd := tr.Update(keyMsg("l"))
+	deliver(t, tr, cmd)
+	if len(bucket.Children) != 2 || bucket.Remaining() != 12343 {
+		t.Fatalf("expected one page, got %d children and %d remaining", len(bucket.Children), bucket.Remaining())
+	}
+	got := viewLines(tr)
+	if want := "… load 2 more (12,343 remaining)"; got[len(got)-1] != want {
+		t.Fatalf("expected %q, got %q", want, got[len(got)-1])
+	}
+
+	// Move onto the "load more" row and activate it
+	tr.SelectLast()
+	if tr.ActiveItem.placeholder != statusMore {
+		t.Fatalf("expected the load more row to be selectable, got %v", tr.ActiveItem)
+	}
+	_, cmd = tr.Update(keyMsg("enter"))
+	deliver(t, tr, cmd)
+	if !reflect.DeepEqual(offsets, []int{0, 2}) {
+		t.Fatalf("expected pages at 0 and 2, got %v", offsets)
+	}
+	if len(bucket.Children) != 4 || tr.ActiveItem.Name != "obj2" {
+		t.Fatalf("expected the cursor on the first new child, got %d children and %s active", len(bucket.Children), tr.ActiveItem.Name)
+	}
+}
+
+func TestPagedLoadLastPage(t *testing.T) {
+	tr := newTodoTree(20)
+	tr.ChildPageSize = 2
+	var offsets []int
+	bucket := newPagedItem(3, &offsets)
+	tr.AddChildren(bucket)
+
+	bucket.ToggleChildren()
+	deliver(t, tr, tr.FlushCmds())
+	got := viewLines(tr)
+	if want := "… load 1 more (1 remaining)"; got[len(got)-1] != want {
+		t.Fatalf("expected %q, got %q", want, got[len(got)-1])
+	}
+	tr.LoadMore(bucket)
+	deliver(t, tr, tr.FlushCmds())
+	if len(bucket.Children) != 3 || bucket.Remaining() != 0 {
+		t.Fatalf("expected all 3 children, got %d", len(bucket.Children))
+	}
+	if got := viewLines(tr); got[len(got)-1] != "obj2" {
+		t.Fatalf("expected the load more row to be gone, got %v", got)
+	}
+}
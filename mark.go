@@ -0,0 +1,80 @@
+package teatree
+
+// ToggleMark marks the active item, or unmarks it if it was already marked
+func (t *Tree) ToggleMark() {
+	if t.ActiveItem != nil && t.ActiveItem.placeholder == statusNone {
+		t.ActiveItem.Marked = !t.ActiveItem.Marked
+		t.markAnchor = t.ActiveItem
+	}
+}
+
+// markAndMove marks the active item, moves the cursor, and marks the item it lands on. This is
+// what extends a range of marks with shift+up and shift+down.
+func (t *Tree) markAndMove(delta int) {
+	if t.ActiveItem == nil {
+		return
+	}
+	t.ActiveItem.mark()
+	t.moveCursor(delta)
+	t.ActiveItem.mark()
+}
+
+// mark marks the item, unless it is a placeholder row
+func (ti *TreeItem) mark() {
+	if ti.placeholder == statusNone {
+		ti.Marked = true
+	}
+}
+
+// MarkRange marks every visible row between from and to, inclusive. The rows can be in either
+// order. Nothing is marked if either item isn't visible.
+func (t *Tree) MarkRange(from, to *TreeItem) {
+	rows := t.visibleItems()
+	a, b := rowIndex(rows, from), rowIndex(rows, to)
+	if a < 0 || b < 0 {
+		return
+	}
+	if a > b {
+		a, b = b, a
+	}
+	for _, item := range rows[a : b+1] {
+		item.mark()
+	}
+}
+
+// ToggleMarkChildren marks all of the loaded children of the active item. If they are all
+// marked already, they are unmarked instead.
+func (t *Tree) ToggleMarkChildren() {
+	if t.ActiveItem == nil || len(t.ActiveItem.Children) == 0 {
+		return
+	}
+	mark := false
+	for _, child := range t.ActiveItem.Children {
+		if !child.Marked {
+			mark = true
+			break
+		}
+	}
+	for _, child := range t.ActiveItem.Children {
+		child.Marked = mark
+	}
+}
+
+// MarkedItems returns every marked item in tree order, including ones inside closed items
+func (t *Tree) MarkedItems() []*TreeItem {
+	var marked []*TreeItem
+	for _, item := range loadedItems(nil, t.Items) {
+		if item.Marked {
+			marked = append(marked, item)
+		}
+	}
+	return marked
+}
+
+// ClearMarks unmarks every item in the tree
+func (t *Tree) ClearMarks() {
+	for _, item := range loadedItems(nil, t.Items) {
+		item.Marked = false
+	}
+	t.markAnchor = nil
+}
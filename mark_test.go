@@ -0,0 +1,94 @@
+package teatree
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func names(items []*TreeItem) []string {
+	var s []string
+	for _, ti := range items {
+		s = append(s, ti.Name)
+	}
+	return s
+}
+
+func TestMarkWithSpace(t *testing.T) {
+	tr := newTodoTree(10)
+
+	// Without MultiSelect, space still opens and closes
+	tr.Update(keyMsg(" "))
+	if !tr.Items[0].Open || tr.Items[0].Marked {
+		t.Fatal("expected space to open Item 1 when not in MultiSelect")
+	}
+
+	tr.MultiSelect = true
+	tr.Update(keyMsg(" "))
+	if !tr.Items[0].Marked || !tr.Items[0].Open {
+		t.Fatal("expected space to mark Item 1 in MultiSelect")
+	}
+	tr.Update(keyMsg(" "))
+	if tr.Items[0].Marked {
+		t.Fatal("expected space to unmark Item 1")
+	}
+}
+
+func TestMarkedItemsInTreeOrder(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.MultiSelect = true
+	openAll(tr.Items)
+
+	tr.SetActive(find(tr, "Item 3", "CC"))
+	tr.ToggleMark()
+	tr.SetActive(find(tr, "Item 2"))
+	tr.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	tr.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+
+	// Closed items keep their marks
+	find(tr, "Item 3").ToggleChildren()
+	want := []string{"Item 2", "AA", "BB", "CC"}
+	if got := names(tr.MarkedItems()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	tr.ClearMarks()
+	if got := tr.MarkedItems(); len(got) != 0 {
+		t.Fatalf("expected no marks, got %v", names(got))
+	}
+}
+
+func TestMarkChildren(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.MultiSelect = true
+	tr.SetActive(tr.Items[1])
+
+	tr.Update(keyMsg("*"))
+	if got := names(tr.MarkedItems()); !reflect.DeepEqual(got, []string{"AA", "BB"}) {
+		t.Fatalf("expected AA and BB to be marked, got %v", got)
+	}
+	tr.Update(keyMsg("*"))
+	if got := tr.MarkedItems(); len(got) != 0 {
+		t.Fatalf("expected the children to be unmarked, got %v", names(got))
+	}
+}
+
+func TestShiftClickMarksRange(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.MultiSelect = true
+	openAll(tr.Items)
+
+	tr.Update(click(8, 1))
+	shift := click(8, 4)
+	shift.Shift = true
+	tr.Update(shift)
+
+	want := []string{"Sub 1", "SubSub 1", "Item 2", "AA"}
+	if got := names(tr.MarkedItems()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if tr.ActiveItem.Name != "AA" {
+		t.Fatalf("expected AA to be active, got %s", tr.ActiveItem.Name)
+	}
+}
@@ -0,0 +1,139 @@
+package teatree
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+)
+
+// MemoryProvider is a Provider that serves a tree of paths held in memory, which makes it handy
+// for tests and demos. Its nodes are the paths, as strings, and the top level is the "" path.
+// Paths can be added, removed and made to fail while the tree is showing them, to try out
+// reloading and error handling.
+type MemoryProvider struct {
+	mu       sync.Mutex
+	children map[string][]string // the paths of each node's children, in the order they were added
+	dirs     map[string]bool     // the nodes that can have children
+	errs     map[string]error    // what listing a node's children fails with
+}
+
+// NewMemoryProvider returns a provider with the given paths, (see Add)
+func NewMemoryProvider(paths ...string) *MemoryProvider {
+	p := &MemoryProvider{
+		children: map[string][]string{},
+		dirs:     map[string]bool{"": true},
+		errs:     map[string]error{},
+	}
+	p.Add(paths...)
+	return p
+}
+
+// Add adds nodes by their slash separated paths, along with any of their ancestors that aren't
+// there yet. A path ending in a slash can have children, even if it doesn't have any yet.
+func (p *MemoryProvider) Add(paths ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, full := range paths {
+		dir := strings.HasSuffix(full, "/")
+		parts := strings.Split(strings.Trim(full, "/"), "/")
+		parent := ""
+		for x := range parts {
+			node := strings.Join(parts[:x+1], "/")
+			if !p.has(parent, node) {
+				p.children[parent] = append(p.children[parent], node)
+			}
+			p.dirs[parent] = true
+			parent = node
+		}
+		if dir {
+			p.dirs[parent] = true
+		}
+	}
+}
+
+// Remove takes nodes out, along with everything below them
+func (p *MemoryProvider) Remove(paths ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, full := range paths {
+		node := strings.Trim(full, "/")
+		parent := path.Dir(node)
+		if parent == "." {
+			parent = ""
+		}
+		siblings := p.children[parent]
+		for x, sibling := range siblings {
+			if sibling == node {
+				p.children[parent] = append(siblings[:x:x], siblings[x+1:]...)
+				break
+			}
+		}
+		p.drop(node)
+	}
+}
+
+// SetError makes listing the children of the node at path fail with err, until it is set back
+// to nil. The top level is the "" path.
+func (p *MemoryProvider) SetError(path string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs[strings.Trim(path, "/")] = err
+}
+
+func (p *MemoryProvider) has(parent, node string) bool {
+	for _, child := range p.children[parent] {
+		if child == node {
+			return true
+		}
+	}
+	return false
+}
+
+// drop forgets a node, and everything below it
+func (p *MemoryProvider) drop(node string) {
+	for _, child := range p.children[node] {
+		p.drop(child)
+	}
+	delete(p.children, node)
+	delete(p.dirs, node)
+	delete(p.errs, node)
+}
+
+// Children lists the paths of the children of parent
+func (p *MemoryProvider) Children(ctx context.Context, parent Node) ([]Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key := ""
+	if parent != nil {
+		key = parent.(string)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.errs[key]; err != nil {
+		return nil, err
+	}
+	nodes := make([]Node, 0, len(p.children[key]))
+	for _, child := range p.children[key] {
+		nodes = append(nodes, child)
+	}
+	return nodes, nil
+}
+
+// HasChildren reports whether the node has children, or was added with a trailing slash
+func (p *MemoryProvider) HasChildren(node Node) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dirs[node.(string)]
+}
+
+// Key returns the node's path
+func (p *MemoryProvider) Key(node Node) string {
+	return node.(string)
+}
+
+// Name returns the last element of the node's path
+func (p *MemoryProvider) Name(node Node) string {
+	return path.Base(node.(string))
+}
@@ -0,0 +1,37 @@
+package teatree
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryProvider(t *testing.T) {
+	p := NewMemoryProvider("a/b/c", "a/d/", "e")
+	list := func(parent Node) []Node {
+		t.Helper()
+		nodes, err := p.Children(context.Background(), parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nodes
+	}
+	if got := list(nil); !reflect.DeepEqual(got, []Node{"a", "e"}) {
+		t.Fatalf("expected the top level, got %v", got)
+	}
+	if got := list("a"); !reflect.DeepEqual(got, []Node{"a/b", "a/d"}) {
+		t.Fatalf("expected the children of a, got %v", got)
+	}
+	if !p.HasChildren("a/d") || p.HasChildren("e") || p.Name("a/b/c") != "c" || p.Key("a/b") != "a/b" {
+		t.Fatal("expected a/d to be a directory and e not")
+	}
+
+	p.Remove("a/b")
+	p.Add("a/b/")
+	if got := list("a"); !reflect.DeepEqual(got, []Node{"a/d", "a/b"}) {
+		t.Fatalf("expected a/b to be added again at the end, got %v", got)
+	}
+	if got := list("a/b"); len(got) != 0 {
+		t.Fatalf("expected the old children of a/b to have gone, got %v", got)
+	}
+}
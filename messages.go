@@ -0,0 +1,58 @@
+package teatree
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ItemSelectedMsg is returned (as a tea.Cmd) from Tree.Update when the user activates an item
+// with the Select key, so the parent model can act on the choice.
+type ItemSelectedMsg struct {
+	Item *TreeItem
+	Path []string // Item.GetPath() at the time it was selected
+	Data interface{}
+}
+
+// SelectAction controls what the Select key does to the active item
+type SelectAction int
+
+const (
+	// SelectToggleAndEmit opens or closes the item if it can have children, and sends an ItemSelectedMsg
+	SelectToggleAndEmit SelectAction = iota
+	// SelectToggle only opens or closes the item, nothing is sent to the parent model
+	SelectToggle
+	// SelectEmit only sends an ItemSelectedMsg, leaving the item open or closed as it was
+	SelectEmit
+)
+
+func selectedCmd(ti *TreeItem) tea.Cmd {
+	msg := ItemSelectedMsg{
+		Item: ti,
+		Path: ti.GetPath(),
+		Data: ti.Data,
+	}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// ReachedTopMsg is returned from Tree.Update when the user tries to move up from the first row.
+// A host can use this to move focus to the widget above the tree.
+type ReachedTopMsg struct {
+	Tree *Tree
+}
+
+// ReachedBottomMsg is returned from Tree.Update when the user tries to move down from the last
+// row. A host can use this to move focus to the next widget in a form or multi-pane layout.
+type ReachedBottomMsg struct {
+	Tree *Tree
+}
+
+func (t *Tree) reachedTop() tea.Cmd {
+	return func() tea.Msg {
+		return ReachedTopMsg{Tree: t}
+	}
+}
+
+func (t *Tree) reachedBottom() tea.Cmd {
+	return func() tea.Msg {
+		return ReachedBottomMsg{Tree: t}
+	}
+}
@@ -0,0 +1,80 @@
+package teatree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectEmitsItemSelectedMsg(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.Items[1].Data = 42
+
+	tr.SetActive(tr.Items[1])
+	_, cmd := tr.Update(keyMsg("enter"))
+	if cmd == nil {
+		t.Fatal("expected a command from Select")
+	}
+	msg, ok := cmd().(ItemSelectedMsg)
+	if !ok {
+		t.Fatalf("expected an ItemSelectedMsg, got %T", cmd())
+	}
+	if msg.Item != tr.Items[1] || msg.Data != 42 || strings.Join(msg.Path, "/") != "Item 2" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if !tr.Items[1].Open {
+		t.Fatal("expected the default SelectAction to also open the item")
+	}
+}
+
+func TestSelectAction(t *testing.T) {
+	tr := newTodoTree(10)
+
+	tr.SelectAction = SelectToggle
+	if _, cmd := tr.Update(keyMsg("enter")); cmd != nil {
+		t.Fatal("SelectToggle should not send a message")
+	}
+	if !tr.Items[0].Open {
+		t.Fatal("SelectToggle should open the item")
+	}
+
+	tr.SelectAction = SelectEmit
+	_, cmd := tr.Update(keyMsg("enter"))
+	if cmd == nil {
+		t.Fatal("SelectEmit should send a message")
+	}
+	if !tr.Items[0].Open {
+		t.Fatal("SelectEmit should leave the item open")
+	}
+}
+
+func TestBoundaryMessages(t *testing.T) {
+	tr := newTodoTree(10)
+
+	_, cmd := tr.Update(keyMsg("up"))
+	if cmd == nil {
+		t.Fatal("expected a command moving up from the first row")
+	}
+	if msg, ok := cmd().(ReachedTopMsg); !ok || msg.Tree != tr {
+		t.Fatalf("expected a ReachedTopMsg for this tree, got %#v", cmd())
+	}
+
+	if _, cmd := tr.Update(keyMsg("down")); cmd != nil {
+		t.Fatalf("expected no command moving down inside the tree, got %#v", cmd())
+	}
+
+	tr.SelectLast()
+	_, cmd = tr.Update(keyMsg("down"))
+	if cmd == nil {
+		t.Fatal("expected a command moving down from the last row")
+	}
+	if _, ok := cmd().(ReachedBottomMsg); !ok {
+		t.Fatalf("expected a ReachedBottomMsg, got %#v", cmd())
+	}
+	_, cmd = tr.Update(keyMsg("J"))
+	if cmd == nil {
+		t.Fatal("expected a command paging down from the last row")
+	}
+	if _, ok := cmd().(ReachedBottomMsg); !ok {
+		t.Fatalf("expected a ReachedBottomMsg, got %#v", cmd())
+	}
+}
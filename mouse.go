@@ -0,0 +1,129 @@
+package teatree
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DoubleClickInterval is how close together two clicks on the same row have to be to count as
+// a double click, which selects the item.
+var DoubleClickInterval = 400 * time.Millisecond
+
+// MouseWheelDelta is the number of rows the view scrolls for each turn of the mouse wheel
+const MouseWheelDelta = 3
+
+// rowAt returns the visible item drawn at the given line of the view, or nil if the line is empty
+func (t *Tree) rowAt(line int) *TreeItem {
+	if line < 0 || (t.listHeight() > 0 && line >= t.listHeight()) {
+		return nil
+	}
+	rows := t.visibleItems()
+	x := t.viewtop + line
+	if x >= len(rows) {
+		return nil
+	}
+	return rows[x]
+}
+
+// contains reports whether a screen position is inside the tree. A tree that hasn't been given
+// a size takes everything below and to the right of its offset.
+func (t *Tree) contains(x, y int) bool {
+	x -= t.OffsetX
+	y -= t.OffsetY
+	if x < 0 || y < 0 {
+		return false
+	}
+	return (t.Width <= 0 || x < t.Width) && (t.Height <= 0 || y < t.Height)
+}
+
+// onChevron reports whether column x of a rendered row falls on the item's open/close chevron
+func (ti *TreeItem) onChevron(x int) bool {
+	if !ti.CanHaveChildren {
+		return false
+	}
+	start := ti.rowDepth * ti.ParentTree.indentWidth()
+	return x >= start && x < start+ti.ParentTree.chevronWidth()
+}
+
+// handleMouse maps a mouse event onto the rows of the tree. The wheel scrolls the view without
+// moving the cursor, a left click selects a row, (or toggles it if the click is on the chevron),
+// and a double click selects the item as if the Select key had been pressed. In a MultiSelect
+// tree, shift-click marks a range of rows, and with Checkboxes a click on the box toggles it.
+// While the help overlay is up, a click closes it and nothing else happens.
+func (t *Tree) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	if !t.contains(msg.X, msg.Y) {
+		// It's probably meant for something beside the tree
+		return nil
+	}
+	if t.showingHelp {
+		// The overlay covers the rows, so they can't be clicked or scrolled
+		if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
+			t.ToggleHelp()
+		}
+		return nil
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		t.ScrollUp(MouseWheelDelta)
+		return nil
+	case tea.MouseButtonWheelDown:
+		t.ScrollDown(MouseWheelDelta)
+		return nil
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	ti := t.rowAt(msg.Y - t.OffsetY - t.frameTop() - t.headerHeight())
+	if ti == nil {
+		return nil
+	}
+	if ti.placeholder == statusMore {
+		t.SetActive(ti)
+		t.LoadMore(ti)
+		return nil
+	}
+	if ti.placeholder != statusNone {
+		// Clicking a loading or error row selects the item it belongs to
+		ti = ti.Parent.(*TreeItem)
+	}
+
+	if msg.Shift && t.MultiSelect {
+		// Shift-click marks everything from the last item the user marked or clicked on
+		anchor := t.markAnchor
+		if anchor == nil {
+			anchor = t.ActiveItem
+		}
+		t.MarkRange(anchor, ti)
+		t.SetActive(ti)
+		return nil
+	}
+	t.markAnchor = ti
+
+	now := time.Now()
+	double := ti == t.lastClickItem && now.Sub(t.lastClick) <= DoubleClickInterval
+	t.lastClickItem = ti
+	t.lastClick = now
+
+	t.SetActive(ti)
+	if ti.onChevron(msg.X - t.OffsetX - t.frameLeft()) {
+		// Clicking on the chevron shouldn't start or finish a double click
+		t.lastClickItem = nil
+		ti.ToggleChildren()
+		return nil
+	}
+	if ti.onCheckbox(msg.X - t.OffsetX - t.frameLeft()) {
+		t.lastClickItem = nil
+		return t.ToggleCheck()
+	}
+	if double {
+		// Don't let a third click count as another double click
+		t.lastClickItem = nil
+		return t.Select()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package teatree
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func click(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+}
+
+func TestMouseClickSelects(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+	tr.OffsetY = 2
+
+	// Line 2 of the view is SubSub 1, on screen row 4
+	tr.Update(click(10, 4))
+	if tr.ActiveItem.Name != "SubSub 1" {
+		t.Fatalf("expected SubSub 1, got %s", tr.ActiveItem.Name)
+	}
+	// Clicking outside of the tree does nothing
+	tr.Update(click(10, 0))
+	tr.Update(click(10, 30))
+	if tr.ActiveItem.Name != "SubSub 1" {
+		t.Fatalf("expected clicks outside the tree to be ignored, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestMouseClickChevronToggles(t *testing.T) {
+	tr := newTodoTree(10)
+	openAll(tr.Items)
+
+	// Sub 1 is on line 1, indented once so its chevron is at column 2
+	sub := find(tr, "Item 1", "Sub 1")
+	tr.Update(click(2, 1))
+	if tr.ActiveItem != sub || sub.Open {
+		t.Fatalf("expected Sub 1 to be active and closed, got %s (open %v)", tr.ActiveItem.Name, sub.Open)
+	}
+	// Clicking the label doesn't toggle
+	tr.Update(click(8, 1))
+	if sub.Open {
+		t.Fatal("expected a click on the label not to toggle")
+	}
+}
+
+func TestMouseDoubleClickSelects(t *testing.T) {
+	tr := newTodoTree(10)
+	tr.SelectAction = SelectEmit
+
+	if _, cmd := tr.Update(click(8, 1)); cmd != nil {
+		t.Fatal("expected a single click not to select")
+	}
+	_, cmd := tr.Update(click(8, 1))
+	if cmd == nil {
+		t.Fatal("expected a double click to select")
+	}
+	if msg, ok := cmd().(ItemSelectedMsg); !ok || msg.Item.Name != "Item 2" {
+		t.Fatalf("expected Item 2 to be selected, got %#v", cmd())
+	}
+}
+
+func TestMouseWheelScrolls(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+
+	tr.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown})
+	if tr.viewtop != MouseWheelDelta {
+		t.Fatalf("expected to scroll down %d rows, viewtop is %d", MouseWheelDelta, tr.viewtop)
+	}
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected the wheel not to move the cursor, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown})
+	tr.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown})
+	if tr.viewtop != 5 {
+		t.Fatalf("expected scrolling to stop at the last row, viewtop is %d", tr.viewtop)
+	}
+	tr.Update(tea.MouseMsg{Button: tea.MouseButtonWheelUp})
+	if tr.viewtop != 2 {
+		t.Fatalf("expected to scroll back up, viewtop is %d", tr.viewtop)
+	}
+}
+
+func TestMouseUnderHelpOverlay(t *testing.T) {
+	tr := newTodoTree(3)
+	tr.Width = 80
+	openAll(tr.Items)
+	tr.ToggleHelp()
+
+	tr.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown})
+	if tr.viewtop != 0 || !tr.ShowingHelp() {
+		t.Fatalf("expected the wheel not to scroll the rows under the help, viewtop is %d", tr.viewtop)
+	}
+	tr.Update(click(10, 2))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected the click not to reach the rows under the help, got %s", tr.ActiveItem.Name)
+	}
+	if tr.ShowingHelp() {
+		t.Fatal("expected the click to close the help")
+	}
+}
@@ -0,0 +1,183 @@
+package teatree
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Node is one of the things a Provider hands out. The tree keeps each item's node in its Data.
+type Node = any
+
+// Provider is where a tree gets its items from, as an alternative to building them up front or
+// in OpenFunc closures. The tree asks for a node's children when its item is opened, asks again
+// when it is reloaded, and walks all of them for Search. Its methods can be called from commands
+// running in the background.
+type Provider interface {
+	// Children lists the children of parent, or the top level nodes for a nil parent. ctx is
+	// cancelled if the tree stops waiting for them.
+	Children(ctx context.Context, parent Node) ([]Node, error)
+	// HasChildren reports whether the node can have children, so it gets a chevron
+	HasChildren(node Node) bool
+	// Key identifies the node from one load to the next, see Tree.KeyFunc
+	Key(node Node) string
+	// Name is what the node is called, which is shown in the tree and makes up its path
+	Name(node Node) string
+}
+
+// Decorator can be implemented by a Provider to give the items it fills the tree with icons and
+// colors. Each item's node is in its Data.
+type Decorator interface {
+	Icon(ti *TreeItem) string
+	IconStyle(ti *TreeItem) lipgloss.Style
+	LabelStyle(ti *TreeItem) lipgloss.Style
+}
+
+// SetProvider fills the tree with the top level nodes from p, replacing any items it had. The top
+// level is listed straight away, so the tree has its items as soon as it's set up. Items with
+// children load them in the background when they are opened, with a context derived from ctx.
+func (t *Tree) SetProvider(ctx context.Context, p Provider) error {
+	nodes, err := p.Children(ctx, nil)
+	if err != nil {
+		return err
+	}
+	t.provider = p
+	t.providerCtx = ctx
+	t.providerGen++
+	for _, item := range t.Items {
+		t.forget(item)
+		item.Parent = nil
+		item.setParentTree(nil)
+	}
+	t.Items = nil
+	t.ActiveItem = nil
+	t.AddChildren(t.nodeItems(p, nodes)...)
+	return nil
+}
+
+// Provider returns the tree's Provider, or nil if it wasn't built from one
+func (t *Tree) Provider() Provider {
+	return t.provider
+}
+
+// topLoadedMsg brings the top level nodes that Tree.Reload listed back to the tree
+type topLoadedMsg struct {
+	tree  *Tree
+	gen   int // which SetProvider they came from
+	nodes []Node
+	err   error
+}
+
+// Reload reads the top level nodes again from the tree's Provider, and matches them up with the
+// items that are there, the same way as TreeItem.Reload. The open items below them are reloaded
+// too. The top level is listed in the background, by a command that is returned by Update, or by
+// FlushCmds. A ReloadedMsg reports what changed, or in its Err, why the top level couldn't be
+// listed. It does nothing for a tree without a Provider.
+func (t *Tree) Reload() {
+	if t.provider == nil {
+		return
+	}
+	p, ctx, gen := t.provider, t.providerCtx, t.providerGen
+	t.queue(func() tea.Msg {
+		nodes, err := p.Children(ctx, nil)
+		return topLoadedMsg{tree: t, gen: gen, nodes: nodes, err: err}
+	})
+}
+
+// topLoaded matches up the nodes Reload listed, unless the tree has been given another Provider
+// since
+func (t *Tree) topLoaded(msg topLoadedMsg) tea.Cmd {
+	if msg.gen != t.providerGen {
+		return nil
+	}
+	if msg.err != nil {
+		return func() tea.Msg {
+			return ReloadedMsg{Err: msg.err}
+		}
+	}
+	t.ReloadItems(t.nodeItems(t.provider, msg.nodes))
+	return nil
+}
+
+// Search goes through every node the tree's Provider has, not just the ones that have been
+// loaded, and returns the paths of the ones that match accepts, (see GetPath). Nodes whose
+// children can't be listed are skipped. It doesn't touch the tree, so it can be run in a tea.Cmd,
+// and RevealWhenLoaded can then show what it found. If ctx is done, Search stops and returns what
+// it found so far, along with ctx's error.
+func (t *Tree) Search(ctx context.Context, match func(Node) bool) ([][]string, error) {
+	p := t.provider
+	if p == nil {
+		return nil, nil
+	}
+	var found [][]string
+	var walk func(parent Node, path []string)
+	walk = func(parent Node, path []string) {
+		nodes, err := p.Children(ctx, parent)
+		if err != nil {
+			return
+		}
+		for _, node := range nodes {
+			if ctx.Err() != nil {
+				return
+			}
+			nodePath := append(path[:len(path):len(path)], p.Name(node))
+			if match(node) {
+				found = append(found, nodePath)
+			}
+			if p.HasChildren(node) {
+				walk(node, nodePath)
+			}
+		}
+	}
+	walk(nil, nil)
+	return found, ctx.Err()
+}
+
+// RevealWhenLoaded is Reveal for trees whose children load in the background, like the ones a
+// Provider fills. It opens the items on the way down to path, and makes the item at the end of
+// it active once it has loaded, unless the user presses a key or clicks first. The loads are
+// returned by Update, or by FlushCmds.
+func (t *Tree) RevealWhenLoaded(path []string) {
+	s := State{Active: path, Top: t.viewtop}
+	for x := 1; x < len(path); x++ {
+		s.Open = append(s.Open, path[:x])
+	}
+	t.RestoreState(s)
+}
+
+// nodeItems makes items for nodes from p, the tree's Provider. It's passed in, as the loads call
+// this in the background, where the tree's fields can't be read.
+func (t *Tree) nodeItems(p Provider, nodes []Node) []*TreeItem {
+	items := make([]*TreeItem, 0, len(nodes))
+	decorator, _ := p.(Decorator)
+	for _, node := range nodes {
+		ti := NewItem(p.Name(node), p.HasChildren(node), nil, nil, nil, nil, nil, nil, node)
+		if decorator != nil {
+			ti.icon = decorator.Icon
+			ti.iconStyle = decorator.IconStyle
+			ti.labelStyle = decorator.LabelStyle
+		}
+		if ti.CanHaveChildren {
+			ti.LoadFunc = t.loadNode
+		}
+		items = append(items, ti)
+	}
+	return items
+}
+
+// loadNode is the LoadFunc of the items a Provider fills the tree with
+func (t *Tree) loadNode(ti *TreeItem) tea.Cmd {
+	p := t.provider
+	ctx, cancel := context.WithCancel(t.providerCtx)
+	ti.loadCancel = cancel
+	node := ti.Data
+	return func() tea.Msg {
+		defer cancel()
+		nodes, err := p.Children(ctx, node)
+		if err != nil {
+			return ChildrenLoadedMsg{Item: ti, Err: err}
+		}
+		return ChildrenLoadedMsg{Item: ti, Children: t.nodeItems(p, nodes)}
+	}
+}
@@ -0,0 +1,168 @@
+package teatree
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newProviderTree(t *testing.T, p Provider) *Tree {
+	t.Helper()
+	tr := New().(*Tree)
+	tr.Height = 20
+	if err := tr.SetProvider(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// settle runs cmd and feeds every load it produces back to the tree, along with the loads those
+// start, until there are none left
+func settle(tr *Tree, cmd tea.Cmd) {
+	for cmd != nil {
+		var next []tea.Cmd
+		for _, msg := range runCmd(cmd) {
+			switch msg.(type) {
+			case ChildrenLoadedMsg, topLoadedMsg:
+				_, c := tr.Update(msg)
+				next = append(next, c)
+			}
+		}
+		cmd = tea.Batch(next...)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	p := NewMemoryProvider("src/main.go", "src/lib/util.go", "docs/", "Readme.md")
+	tr := newProviderTree(t, p)
+	if got := joined(tr.Items); got != "src docs Readme.md" {
+		t.Fatalf("expected the top level nodes, got %s", got)
+	}
+	src, docs := find(tr, "src"), find(tr, "docs")
+	if !src.CanHaveChildren || !docs.CanHaveChildren || find(tr, "Readme.md").CanHaveChildren {
+		t.Fatal("expected only the directories to have children")
+	}
+
+	src.ToggleChildren()
+	if !src.Loading() {
+		t.Fatal("expected the children to load in the background")
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if got := joined(src.Children); got != "main.go lib" {
+		t.Fatalf("expected the children of src, got %s", got)
+	}
+	if lib := find(tr, "src", "lib"); lib.Data != "src/lib" || lib.LoadFunc == nil {
+		t.Fatal("expected lib to carry its node and load its own children")
+	}
+
+	// Reloading matches the items up by the provider's keys
+	lib := find(tr, "src", "lib")
+	lib.ToggleChildren()
+	deliver(t, tr, tr.FlushCmds())
+	tr.SetActive(find(tr, "src", "lib", "util.go"))
+	p.Add("src/lib/extra.go", "tests/")
+	p.Remove("src/main.go", "docs")
+	tr.Reload()
+	settle(tr, tr.FlushCmds())
+	if got := joined(tr.Items); got != "src Readme.md tests" {
+		t.Fatalf("expected docs gone and tests added, got %s", got)
+	}
+	if find(tr, "src") != src || find(tr, "src", "lib") != lib || !lib.Open {
+		t.Fatal("expected the open items to be kept")
+	}
+	if got := joined(lib.Children); got != "util.go extra.go" {
+		t.Fatalf("expected the open children to be reloaded, got %s", got)
+	}
+	if tr.ActiveItem.Name != "util.go" {
+		t.Fatalf("expected the cursor to stay put, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestProviderErrorsAndCancel(t *testing.T) {
+	p := NewMemoryProvider("a/b", "c/d")
+	tr := newProviderTree(t, p)
+	p.SetError("a", errors.New("permission denied"))
+	a := find(tr, "a")
+	a.ToggleChildren()
+	deliver(t, tr, tr.FlushCmds())
+	if a.LoadError() == nil || !strings.Contains(a.LoadError().Error(), "permission denied") {
+		t.Fatalf("expected the error to be kept, got %v", a.LoadError())
+	}
+
+	// An abandoned load has its context cancelled
+	c := find(tr, "c")
+	c.ToggleChildren()
+	cmd := tr.FlushCmds()
+	c.Refresh()
+	loaded, _ := loadedMsg(runCmd(cmd))
+	if !errors.Is(loaded.Err, context.Canceled) {
+		t.Fatalf("expected the load to be cancelled, got %v", loaded.Err)
+	}
+
+	// Reloading lists the top level in the background, and reports an error in the ReloadedMsg
+	p.SetError("", errors.New("offline"))
+	tr.Reload()
+	var reloaded []ReloadedMsg
+	for _, msg := range runCmd(tr.FlushCmds()) {
+		_, cmd := tr.Update(msg)
+		for _, msg := range runCmd(cmd) {
+			if r, ok := msg.(ReloadedMsg); ok {
+				reloaded = append(reloaded, r)
+			}
+		}
+	}
+	if len(reloaded) != 1 || reloaded[0].Err == nil || joined(tr.Items) != "a c" {
+		t.Fatalf("expected the error to be reported, with the items left alone, got %v", reloaded)
+	}
+	if err := New().(*Tree).SetProvider(context.Background(), p); err == nil {
+		t.Fatal("expected the top level error to be returned")
+	}
+
+	// A reload started before the tree was given another provider is dropped
+	p.SetError("", nil)
+	tr.Reload()
+	cmd = tr.FlushCmds()
+	if err := tr.SetProvider(context.Background(), NewMemoryProvider("x")); err != nil {
+		t.Fatal(err)
+	}
+	settle(tr, cmd)
+	if got := joined(tr.Items); got != "x" {
+		t.Fatalf("expected the old provider's reload to be dropped, got %s", got)
+	}
+}
+
+func TestProviderSearch(t *testing.T) {
+	p := NewMemoryProvider("a/b/needle", "a/c", "d/e/f/needle", "needle/")
+	tr := newProviderTree(t, p)
+	paths, err := tr.Search(context.Background(), func(node Node) bool {
+		return p.Name(node) == "needle"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"a", "b", "needle"}, {"d", "e", "f", "needle"}, {"needle"}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+	if len(find(tr, "a").Children) != 0 {
+		t.Fatal("expected searching to leave the tree alone")
+	}
+
+	// Revealing one of them loads each level in turn
+	tr.RevealWhenLoaded(paths[1])
+	settle(tr, tr.FlushCmds())
+	if tr.ActiveItem != find(tr, "d", "e", "f", "needle") {
+		t.Fatalf("expected the deep needle to be active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tr.Search(ctx, func(Node) bool { return true }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the search to stop, got %v", err)
+	}
+}
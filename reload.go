@@ -0,0 +1,254 @@
+package teatree
+
+import (
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReloadedMsg is sent after Reload or ReloadItems has brought an item's children up to date. Item
+// is nil when it's the top level items that were reloaded.
+type ReloadedMsg struct {
+	Item    *TreeItem
+	Added   []*TreeItem // Items that weren't there before
+	Removed []*TreeItem // Items that have gone, along with everything below them
+	Err     error       // Why Tree.Reload couldn't list the top level, which is left as it was
+}
+
+// key returns what identifies an item from one load to the next
+func (t *Tree) key(ti *TreeItem) string {
+	switch {
+	case t == nil:
+	case t.KeyFunc != nil:
+		return t.KeyFunc(ti)
+	case t.provider != nil && ti.Data != nil:
+		return t.provider.Key(ti.Data)
+	}
+	return ti.Name
+}
+
+// Reload reads the item's children again, with its LoadFunc or OpenFunc, and matches them up with
+// the children it already has by their keys, (see Tree.KeyFunc). Unlike Refresh, the items that
+// are still there are kept, so they stay open, marked, checked and active. Children whose own
+// children have been read are reloaded too, whether they're open or not, so what's below a closed
+// item carries over as well. Items that have gone are taken out, moving the cursor to a sibling if it was on
+// one of them, and a ReloadedMsg reports what changed. With a LoadFunc this happens once the load
+// finishes, and the old children stay on screen until then.
+func (ti *TreeItem) Reload() {
+	ti.reload(true)
+}
+
+// reload is Reload. hold keeps the cursor on its line, which a reload inside another one leaves
+// to the outer reload, so the rows are only built again once.
+func (ti *TreeItem) reload(hold bool) {
+	tree := ti.ParentTree
+	switch {
+	case ti.LoadFunc != nil:
+		if tree == nil || !ti.loaded || ti.loading {
+			// Nothing has been loaded to match up with, or it's being loaded already
+			return
+		}
+		ti.reloading = true
+		tree.startLoad(ti)
+	case ti.OpenFunc != nil && (ti.Open || len(ti.Children) > 0):
+		// The OpenFunc adds the children itself, so let it add them to an empty list that isn't
+		// part of the tree, and then match that list up with the old one
+		old := ti.Children
+		ti.Children = nil
+		ti.ParentTree = nil
+		ti.OpenFunc(ti)
+		fresh := ti.Children
+		ti.Children = old
+		ti.ParentTree = tree
+		ti.reloadWith(fresh, hold)
+	}
+}
+
+// ReloadWith matches new children up with the ones the item already has, the same way as Reload,
+// for when the host has listed the children itself
+func (ti *TreeItem) ReloadWith(children []*TreeItem) (added, removed []*TreeItem) {
+	return ti.reloadWith(children, true)
+}
+
+// reloadWith is ReloadWith, with hold as for reload
+func (ti *TreeItem) reloadWith(children []*TreeItem, hold bool) (added, removed []*TreeItem) {
+	tree := ti.ParentTree
+	if tree == nil {
+		ti.Children, added, removed = reconcile(nil, ti.Children, children)
+		for _, child := range ti.Children {
+			child.Parent = ti
+		}
+		return added, removed
+	}
+	if hold {
+		tree.holdActiveLine(func() {
+			added, removed = tree.reconcileInto(ti, &ti.Children, children)
+		})
+	} else {
+		added, removed = tree.reconcileInto(ti, &ti.Children, children)
+	}
+	tree.queue(reloadedCmd(ti, added, removed))
+	return added, removed
+}
+
+// ReloadItems matches a new list of top level items up with the tree's, the same way as Reload
+func (t *Tree) ReloadItems(items []*TreeItem) (added, removed []*TreeItem) {
+	t.holdActiveLine(func() {
+		added, removed = t.reconcileInto(nil, &t.Items, items)
+		if t.ActiveItem == nil && len(t.Items) > 0 {
+			t.ActiveItem = t.Items[0]
+		}
+	})
+	t.queue(reloadedCmd(nil, added, removed))
+	return added, removed
+}
+
+func reloadedCmd(ti *TreeItem, added, removed []*TreeItem) tea.Cmd {
+	msg := ReloadedMsg{Item: ti, Added: added, Removed: removed}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// reconcileInto replaces a list of children, (parent's, or the top level items for a nil parent),
+// with the reconciled list, and sorts out everything that hangs off the items that came and went
+func (t *Tree) reconcileInto(parent *TreeItem, list *[]*TreeItem, fresh []*TreeItem) (added, removed []*TreeItem) {
+	old := *list
+	result, added, removed := reconcile(t, old, fresh)
+
+	// The cursor only moves if its item has gone
+	for _, gone := range removed {
+		if gone.contains(t.ActiveItem) {
+			t.ActiveItem = survivor(old, result, gone, parent)
+			break
+		}
+	}
+	for _, gone := range removed {
+		t.forget(gone)
+		gone.Parent = nil
+		gone.setParentTree(nil)
+	}
+
+	var holder ItemHolder = t
+	if parent != nil {
+		holder = parent
+	}
+	for _, item := range result {
+		item.Parent = holder
+	}
+	for _, item := range added {
+		item.setParentTree(t)
+	}
+	if parent != nil {
+		parent.Lock()
+		parent.Children = result
+		parent.Unlock()
+	} else {
+		t.Lock()
+		t.Items = result
+		t.Unlock()
+	}
+	// The kept items' children were sorted when they were reconciled themselves
+	if less := t.lessFor(parent); less != nil {
+		sort.SliceStable(result, func(i, j int) bool {
+			return less(result[i], result[j])
+		})
+	}
+	for _, item := range added {
+		t.sortItems(item, item.Children)
+	}
+
+	if parent != nil {
+		parent.adoptChecks(added)
+		parent.deriveCheck()
+		parent.updateAncestorChecks()
+	}
+	return added, removed
+}
+
+// reconcile matches fresh items up with old ones by key. Old items that are matched are kept,
+// updated from their fresh counterparts, and fresh items without a match are added. The result
+// is in the fresh order.
+func reconcile(t *Tree, old, fresh []*TreeItem) (result, added, removed []*TreeItem) {
+	// Items with the same key are matched up in order
+	byKey := make(map[string][]*TreeItem, len(old))
+	for _, item := range old {
+		k := t.key(item)
+		byKey[k] = append(byKey[k], item)
+	}
+	kept := make(map[*TreeItem]bool, len(old))
+	for _, item := range fresh {
+		k := t.key(item)
+		if matches := byKey[k]; len(matches) > 0 {
+			byKey[k] = matches[1:]
+			kept[matches[0]] = true
+			matches[0].update(item)
+			result = append(result, matches[0])
+			continue
+		}
+		result = append(result, item)
+		added = append(added, item)
+	}
+	for _, item := range old {
+		if !kept[item] {
+			removed = append(removed, item)
+		}
+	}
+	return result, added, removed
+}
+
+// update brings a kept item up to date with the fresh copy that was loaded in its place. What the
+// user has done to it, like opening or marking it, is left alone. It runs inside the reconcile of
+// its parent, which keeps the cursor on its line afterwards, so nothing here scrolls.
+func (ti *TreeItem) update(fresh *TreeItem) {
+	ti.Name = fresh.Name
+	ti.Data = fresh.Data
+	ti.icon = fresh.icon
+	ti.labelStyle = fresh.labelStyle
+	ti.iconStyle = fresh.iconStyle
+	ti.CanHaveChildren = fresh.CanHaveChildren
+	ti.OpenFunc = fresh.OpenFunc
+	ti.CloseFunc = fresh.CloseFunc
+	ti.LoadFunc = fresh.LoadFunc
+	ti.Less = fresh.Less
+
+	switch {
+	case len(fresh.Children) > 0:
+		// The loader brought the grandchildren along
+		ti.reloadWith(fresh.Children, false)
+	case ti.Open || ti.loaded || len(ti.Children) > 0:
+		// Closed items have their children reloaded too, so the marks and checks below them
+		// carry over. The ones that were never opened are left to read them when they are.
+		ti.reload(false)
+	}
+}
+
+// survivor picks the item the cursor moves to when its own item has gone: the next item after
+// it in the old list that is still there, or failing that the one before it, or the parent
+func survivor(old, result []*TreeItem, gone *TreeItem, parent *TreeItem) *TreeItem {
+	kept := make(map[*TreeItem]bool, len(result))
+	for _, item := range result {
+		kept[item] = true
+	}
+	x := 0
+	for x < len(old) && old[x] != gone {
+		x++
+	}
+	for y := x + 1; y < len(old); y++ {
+		if kept[old[y]] {
+			return old[y]
+		}
+	}
+	for y := x - 1; y >= 0; y-- {
+		if kept[old[y]] {
+			return old[y]
+		}
+	}
+	if parent != nil {
+		return parent
+	}
+	if len(result) > 0 {
+		return result[0]
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package teatree
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newListedDir returns an item whose OpenFunc reads its children from listing, like a directory.
+// The children that have listings of their own are directories too.
+func newListedDir(name string, listing map[string][]string) *TreeItem {
+	_, isDir := listing[name]
+	dir := NewItem(name, isDir, nil, nil, nil, nil, nil, nil, nil)
+	if isDir {
+		dir.OpenFunc = func(ti *TreeItem) {
+			if len(ti.Children) > 0 {
+				return
+			}
+			var children []*TreeItem
+			for _, child := range listing[name] {
+				children = append(children, newListedDir(child, listing))
+			}
+			ti.AddChildren(children...)
+		}
+	}
+	return dir
+}
+
+// reloadedMsgs returns the ReloadedMsgs among the tree's pending commands, keyed by the reloaded
+// item's name
+func reloadedMsgs(tr *Tree) map[string]ReloadedMsg {
+	msgs := map[string]ReloadedMsg{}
+	for _, msg := range runCmd(tr.FlushCmds()) {
+		if reloaded, ok := msg.(ReloadedMsg); ok {
+			name := ""
+			if reloaded.Item != nil {
+				name = reloaded.Item.Name
+			}
+			msgs[name] = reloaded
+		}
+	}
+	return msgs
+}
+
+func TestReloadKeepsState(t *testing.T) {
+	listing := map[string][]string{"dir": {"a", "b", "c"}, "b": {"x", "y"}}
+	tr := newTodoTree(20)
+	dir := newListedDir("dir", listing)
+	tr.AddChildren(dir)
+	dir.ToggleChildren()
+	a, b := find(tr, "dir", "a"), find(tr, "dir", "b")
+	b.ToggleChildren()
+	y := find(tr, "dir", "b", "y")
+	a.Marked = true
+	tr.SetActive(y)
+	tr.FlushCmds()
+
+	listing["dir"] = []string{"a", "b", "d"}
+	listing["b"] = []string{"y", "z"}
+	dir.Reload()
+
+	if got := joined(dir.Children); got != "a b d" {
+		t.Fatalf("expected c to be replaced by d, got %s", got)
+	}
+	if find(tr, "dir", "a") != a || !a.Marked {
+		t.Fatal("expected a to be kept, and still marked")
+	}
+	if find(tr, "dir", "b") != b || !b.Open || joined(b.Children) != "y z" {
+		t.Fatalf("expected b to be kept open and reloaded, got %s", joined(b.Children))
+	}
+	if tr.ActiveItem != y {
+		t.Fatalf("expected y to stay active, got %s", tr.ActiveItem.Name)
+	}
+	if d := find(tr, "dir", "d"); d.ParentTree != tr || d.Parent != ItemHolder(dir) {
+		t.Fatal("expected d to be attached to the tree")
+	}
+
+	msgs := reloadedMsgs(tr)
+	if got := msgs["dir"]; !reflect.DeepEqual(names(got.Added), []string{"d"}) || !reflect.DeepEqual(names(got.Removed), []string{"c"}) {
+		t.Fatalf("expected d added and c removed, got %v and %v", names(got.Added), names(got.Removed))
+	}
+	if got := msgs["b"]; !reflect.DeepEqual(names(got.Added), []string{"z"}) || !reflect.DeepEqual(names(got.Removed), []string{"x"}) {
+		t.Fatalf("expected z added and x removed under b, got %v and %v", names(got.Added), names(got.Removed))
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestReloadMovesActiveOffRemoved(t *testing.T) {
+	listing := map[string][]string{"dir": {"a", "b", "c"}, "b": {"x"}}
+	tr := newTodoTree(20)
+	dir := newListedDir("dir", listing)
+	tr.AddChildren(dir)
+	dir.ToggleChildren()
+	find(tr, "dir", "b").ToggleChildren()
+	x := find(tr, "dir", "b", "x")
+	tr.SetActive(x)
+
+	// The whole of b goes, with the cursor inside it
+	listing["dir"] = []string{"a", "c"}
+	dir.Reload()
+	if tr.ActiveItem.Name != "c" {
+		t.Fatalf("expected the next sibling to become active, got %s", tr.ActiveItem.Name)
+	}
+	if x.ParentTree != nil {
+		t.Fatal("expected the removed items to be cut loose from the tree")
+	}
+
+	listing["dir"] = []string{"a"}
+	dir.Reload()
+	if tr.ActiveItem.Name != "a" {
+		t.Fatalf("expected the previous sibling to become active, got %s", tr.ActiveItem.Name)
+	}
+	listing["dir"] = nil
+	dir.Reload()
+	if tr.ActiveItem != dir {
+		t.Fatalf("expected the parent to become active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestReloadKeepsClosedChildren(t *testing.T) {
+	listing := map[string][]string{"dir": {"a", "b"}, "b": {"x", "y"}}
+	tr := newTodoTree(20)
+	tr.Checkboxes = true
+	dir := newListedDir("dir", listing)
+	tr.AddChildren(dir)
+	dir.ToggleChildren()
+	b := find(tr, "dir", "b")
+	b.ToggleChildren()
+	x, y := find(tr, "dir", "b", "x"), find(tr, "dir", "b", "y")
+	x.Marked = true
+	y.SetChecked(true)
+	b.ToggleChildren()
+	tr.FlushCmds()
+
+	listing["b"] = []string{"x", "y", "z"}
+	dir.Reload()
+	if b.Open || joined(b.Children) != "x y z" {
+		t.Fatalf("expected b to stay closed, with its children reloaded, got %s", joined(b.Children))
+	}
+	if marked := tr.MarkedItems(); len(marked) != 1 || marked[0] != x {
+		t.Fatalf("expected x to stay marked, got %v", names(marked))
+	}
+	if checked := tr.CheckedItems(); len(checked) != 1 || checked[0] != y || b.CheckState() != Indeterminate {
+		t.Fatalf("expected y to stay checked, got %v", names(checked))
+	}
+	if got := reloadedMsgs(tr)["b"]; !reflect.DeepEqual(names(got.Added), []string{"z"}) {
+		t.Fatalf("expected z to be reported under b, got %v", names(got.Added))
+	}
+
+	// A closed item with a LoadFunc reads its children in the background
+	lazyNames := []string{"one", "two"}
+	newLazy := func() *TreeItem {
+		lazy := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+		lazy.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) {
+			var children []*TreeItem
+			for _, name := range lazyNames {
+				children = append(children, item(name))
+			}
+			return children, nil
+		})
+		return lazy
+	}
+	lazy := newLazy()
+	tr.AddChildren(lazy)
+	lazy.ToggleChildren()
+	deliver(t, tr, tr.FlushCmds())
+	two := find(tr, "lazy", "two")
+	two.Marked = true
+	lazy.ToggleChildren()
+
+	lazyNames = []string{"two", "three"}
+	tr.ReloadItems([]*TreeItem{newListedDir("dir", listing), newLazy()})
+	if find(tr, "lazy") != lazy || joined(lazy.Children) != "one two" {
+		t.Fatal("expected lazy to be kept, with its old children until the new ones arrive")
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if lazy.Open || joined(lazy.Children) != "two three" || find(tr, "lazy", "two") != two || !two.Marked {
+		t.Fatalf("expected lazy to stay closed, with two still marked, got %s", joined(lazy.Children))
+	}
+}
+
+func TestReloadAsync(t *testing.T) {
+	tr := newTodoTree(20)
+	tr.ChildPageSize = 2
+	var offsets []int
+	bucket := newPagedItem(5, &offsets)
+	tr.AddChildren(bucket)
+	bucket.ToggleChildren()
+	deliver(t, tr, tr.FlushCmds())
+	tr.LoadMore(bucket)
+	deliver(t, tr, tr.FlushCmds())
+	obj1 := find(tr, "bucket", "obj1")
+	tr.SetActive(obj1)
+
+	offsets = nil
+	bucket.Reload()
+	if len(bucket.Children) != 4 {
+		t.Fatal("expected the old children to stay until the reload finishes")
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if !reflect.DeepEqual(offsets, []int{0}) {
+		t.Fatalf("expected everything that was paged in to be read again from the start, got %v", offsets)
+	}
+	if got := joined(bucket.Children); got != "obj0 obj1 obj2 obj3" || bucket.Remaining() != 1 {
+		t.Fatalf("expected the same 4 children with 1 remaining, got %s and %d", got, bucket.Remaining())
+	}
+	if tr.ActiveItem != obj1 {
+		t.Fatalf("expected obj1 to stay active, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestReloadItemsWithKeyFunc(t *testing.T) {
+	tr := newTodoTree(20)
+	tr.KeyFunc = func(ti *TreeItem) string {
+		id, _ := ti.Data.(string)
+		return id
+	}
+	withID := func(name, id string) *TreeItem {
+		ti := item(name)
+		ti.Data = id
+		return ti
+	}
+	tr.Items = nil
+	tr.AddChildren(withID("one", "1"), withID("two", "2"))
+	two := find(tr, "two")
+	tr.SetActive(two)
+
+	added, removed := tr.ReloadItems([]*TreeItem{withID("deux", "2"), withID("trois", "3")})
+	if got := joined(tr.Items); got != "deux trois" {
+		t.Fatalf("expected the items in their new order, got %s", got)
+	}
+	if tr.Items[0] != two || tr.ActiveItem != two {
+		t.Fatal("expected the renamed item to be kept, and still active")
+	}
+	if joined(added) != "trois" || joined(removed) != "one" {
+		t.Fatalf("expected trois added and one removed, got %s and %s", joined(added), joined(removed))
+	}
+}
+
+func TestReloadManyChildren(t *testing.T) {
+	// Every child is a closed directory, which used to make the reload scroll once per child
+	const n = 10000
+	listing := map[string][]string{"dir": nil}
+	for x := 0; x < n; x++ {
+		name := fmt.Sprintf("item%05d", x)
+		listing["dir"] = append(listing["dir"], name)
+		listing[name] = nil
+	}
+	tr := newTodoTree(20)
+	dir := newListedDir("dir", listing)
+	tr.AddChildren(dir)
+	dir.ToggleChildren()
+	tr.SetActive(dir.Children[n/2])
+
+	start := time.Now()
+	dir.Reload()
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("expected reloading %d children to take well under a second, took %s", n, took)
+	}
+	if len(dir.Children) != n || tr.ActiveItem.Name != fmt.Sprintf("item%05d", n/2) {
+		t.Fatal("expected the children and the cursor to be kept")
+	}
+	checkActiveOnScreen(t, tr)
+}
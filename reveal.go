@@ -0,0 +1,93 @@
+package teatree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned (wrapped) when a path doesn't lead to an item in the tree
+var ErrNotFound = errors.New("item not found")
+
+// ErrLoading is returned (wrapped) by Reveal when the path leads into an item whose children are
+// still loading in the background. RevealWhenLoaded waits for them instead.
+var ErrLoading = errors.New("children still loading")
+
+// Reveal is the inverse of GetPath: it finds the item at the given path, opening every ancestor
+// on the way down, (which runs any lazy OpenFunc loaders), then makes it the active item and
+// scrolls so that it is on screen. If a segment of the path doesn't exist, an error is returned
+// and the ancestors that were found are left open. If an ancestor has a LoadFunc, its children
+// can't be looked through until they arrive, so an error wrapping ErrLoading is returned; the load
+// is returned by FlushCmds.
+func (t *Tree) Reveal(path []string) (*TreeItem, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("teatree: empty path: %w", ErrNotFound)
+	}
+	items := t.Items
+	var found *TreeItem
+	for x, name := range path {
+		if found != nil {
+			found.openChildren()
+			if found.loading {
+				return nil, fmt.Errorf("teatree: %q is loading: %w", strings.Join(path[:x], "/"), ErrLoading)
+			}
+			items = found.Children
+		}
+		found = nil
+		for _, item := range items {
+			if item.Name == name {
+				found = item
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("teatree: no %q in %q: %w", name, strings.Join(path[:x], "/"), ErrNotFound)
+		}
+	}
+	t.show(found)
+	return found, nil
+}
+
+// RevealItem opens every ancestor of the item so that it is visible, makes it the active item and
+// scrolls so that it is on screen. The item has to have been added to this tree.
+func (t *Tree) RevealItem(ti *TreeItem) error {
+	if ti == nil {
+		return fmt.Errorf("teatree: nil item: %w", ErrNotFound)
+	}
+	var ancestors []*TreeItem
+	var holder ItemHolder = ti
+	for {
+		par := holder.GetParent()
+		if par == nil {
+			break
+		}
+		if item, ok := par.(*TreeItem); ok {
+			ancestors = append(ancestors, item)
+		}
+		holder = par
+	}
+	if holder != ItemHolder(t) {
+		return fmt.Errorf("teatree: %q is not in this tree: %w", ti.Name, ErrNotFound)
+	}
+	for x := len(ancestors) - 1; x >= 0; x-- {
+		ancestors[x].openChildren()
+	}
+	t.show(ti)
+	return nil
+}
+
+// show makes an item that has had its ancestors opened active. A filter that might be hiding
+// it is dropped first.
+func (t *Tree) show(ti *TreeItem) {
+	if t.filterState != Unfiltered && !t.filterShown[ti] {
+		t.ClearFilter()
+	}
+	t.SetActive(ti)
+}
+
+// openChildren opens the item, running its OpenFunc, if it isn't already open
+func (ti *TreeItem) openChildren() {
+	if ti.CanHaveChildren && !ti.Open {
+		ti.ToggleChildren()
+	}
+}
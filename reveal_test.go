@@ -0,0 +1,118 @@
+package teatree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lazyItem creates a folder that only gets its children when it is opened
+func lazyItem(name string, loads *int, children ...func() *TreeItem) *TreeItem {
+	open := func(ti *TreeItem) {
+		if len(ti.Children) == 0 {
+			*loads++
+			for _, child := range children {
+				ti.AddChildren(child())
+			}
+		}
+	}
+	return NewItem(name, true, nil, nil, nil, nil, open, nil, nil)
+}
+
+func newLazyTree(loads *int) *Tree {
+	tr := New().(*Tree)
+	tr.Height = 3
+	tr.AddChildren(
+		item("etc", item("hosts"), item("passwd")),
+		lazyItem("home", loads, func() *TreeItem {
+			return lazyItem("cfox", loads, func() *TreeItem {
+				return lazyItem("work", loads,
+					func() *TreeItem { return lazyItem("testproj", loads, func() *TreeItem { return item("game1") }) },
+					func() *TreeItem { return item("mgrthing") },
+				)
+			})
+		}),
+	)
+	return tr
+}
+
+func TestReveal(t *testing.T) {
+	var loads int
+	tr := newLazyTree(&loads)
+
+	ti, err := tr.Reveal(strings.Split("home/cfox/work/testproj", "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ti.Name != "testproj" || tr.ActiveItem != ti {
+		t.Fatalf("expected testproj to be active, got %s", tr.ActiveItem.Name)
+	}
+	if loads != 3 {
+		t.Fatalf("expected home, cfox and work to be loaded, got %d loads", loads)
+	}
+	if ti.Open {
+		t.Fatal("expected the revealed item itself to stay closed")
+	}
+	if got := strings.Join(ti.GetPath(), "/"); got != "home/cfox/work/testproj" {
+		t.Fatalf("unexpected path %s", got)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestRevealMissing(t *testing.T) {
+	var loads int
+	tr := newLazyTree(&loads)
+
+	_, err := tr.Reveal([]string{"home", "cfox", "play"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"play"`) {
+		t.Fatalf("expected the error to name the missing segment, got %v", err)
+	}
+	if tr.ActiveItem != tr.Items[0] {
+		t.Fatalf("expected the cursor not to move, got %s", tr.ActiveItem.Name)
+	}
+	if _, err := tr.Reveal(nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an empty path, got %v", err)
+	}
+}
+
+func TestRevealWhileLoading(t *testing.T) {
+	tr := newTodoTree(10)
+	lazy := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+	lazy.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) {
+		return []*TreeItem{item("one")}, nil
+	})
+	tr.AddChildren(lazy)
+
+	_, err := tr.Reveal([]string{"lazy", "one"})
+	if !errors.Is(err, ErrLoading) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrLoading, got %v", err)
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if found, err := tr.Reveal([]string{"lazy", "one"}); err != nil || tr.ActiveItem != found {
+		t.Fatalf("expected one to be revealed once it has loaded, got %v", err)
+	}
+}
+
+func TestRevealItem(t *testing.T) {
+	var loads int
+	tr := newLazyTree(&loads)
+	passwd := tr.Items[0].Children[1]
+
+	if err := tr.RevealItem(passwd); err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Items[0].Open || tr.ActiveItem != passwd {
+		t.Fatalf("expected etc to be opened and passwd active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+
+	if err := tr.RevealItem(item("stray")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an item outside the tree, got %v", err)
+	}
+	if err := tr.RevealItem(nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a nil item, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package teatree
+
+import "github.com/charmbracelet/lipgloss"
+
+// The tree is scrolled by treating everything that is currently visible, (top level items and
+// the children of any open item, recursively), as one flat list of rows. viewtop is the index of
+// the first row on screen, and the cursor is just the index of the ActiveItem in that list.
+//
+// The list is cached, and only rebuilt after something changes which items are visible, so
+// drawing a frame or moving the cursor costs the same no matter how big the tree is.
+
+// visibleItems flattens the open parts of the tree into the list of items that make up each
+// rendered line, from top to bottom
+func (t *Tree) visibleItems() []*TreeItem {
+	if !t.rowsValid {
+		t.rows = t.appendVisible(make([]*TreeItem, 0, len(t.rows)), t.displayed(t.Items), 0)
+		t.rowsValid = true
+	}
+	return t.rows
+}
+
+func (t *Tree) appendVisible(rows []*TreeItem, items []*TreeItem, depth int) []*TreeItem {
+	for x, item := range items {
+		item.row = len(rows)
+		item.rowDepth = depth
+		item.rowLast = x == len(items)-1
+		rows = append(rows, item)
+		rows = t.appendVisible(rows, t.displayedChildren(item), depth+1)
+	}
+	return rows
+}
+
+// Invalidate tells the tree that the visible rows may have changed. The tree's own methods do
+// this for you, it's only needed after changing Open, Children or Items directly.
+func (t *Tree) Invalidate() {
+	t.rowsValid = false
+}
+
+func (ti *TreeItem) invalidate() {
+	if ti.ParentTree != nil {
+		ti.ParentTree.Invalidate()
+	}
+}
+
+// displayedChildren returns the children that are drawn below an item. This is all of them when
+// the item is open, or when a filter is applied, only the ones that match or lead to a match.
+func (t *Tree) displayedChildren(ti *TreeItem) []*TreeItem {
+	if t.filterShown != nil {
+		return t.displayed(ti.Children)
+	}
+	if ti.Open {
+		if ti.statusRow != nil {
+			return append(ti.Children[:len(ti.Children):len(ti.Children)], ti.statusRow)
+		}
+		return ti.Children
+	}
+	return nil
+}
+
+// displayed returns the items that pass the current filter
+func (t *Tree) displayed(items []*TreeItem) []*TreeItem {
+	if t.filterShown == nil {
+		return items
+	}
+	var shown []*TreeItem
+	for _, item := range items {
+		if t.filterShown[item] {
+			shown = append(shown, item)
+		}
+	}
+	return shown
+}
+
+// listHeight is the number of lines available for rows, after the frame, the title, the filter
+// input and the column header have been drawn
+func (t *Tree) listHeight() int {
+	height := t.innerHeight()
+	if height <= 0 {
+		return height
+	}
+	if h := height - t.headerHeight(); h > 0 {
+		return h
+	}
+	return 1
+}
+
+// headerHeight is the number of lines drawn above the rows
+func (t *Tree) headerHeight() int {
+	var h int
+	if t.Title != "" {
+		h += lipgloss.Height(t.Styles.Title.Render(t.Title))
+	}
+	if t.filterState != Unfiltered {
+		h++
+	}
+	if len(t.Columns) > 0 {
+		h++
+	}
+	return h
+}
+
+// rowIndex returns where the item is in the visible rows, or -1 if it isn't visible
+func rowIndex(rows []*TreeItem, ti *TreeItem) int {
+	if ti == nil || ti.row < 0 || ti.row >= len(rows) || rows[ti.row] != ti {
+		return -1
+	}
+	return ti.row
+}
+
+// moveCursor moves the active item by delta rows, stopping at the first or last row. It returns
+// false if the cursor couldn't move at all because it was already on the first or last row.
+func (t *Tree) moveCursor(delta int) bool {
+	if t.ActiveItem == nil {
+		return false
+	}
+	return t.moveFrom(t.ActiveItem, delta)
+}
+
+func (t *Tree) moveFrom(ti *TreeItem, delta int) bool {
+	rows := t.visibleItems()
+	x := rowIndex(rows, ti)
+	if x < 0 {
+		return false
+	}
+	newx := x + delta
+	if newx < 0 {
+		newx = 0
+	}
+	if newx >= len(rows) {
+		newx = len(rows) - 1
+	}
+
+	// Loading and error rows can't be selected, so keep going past them. If there's nothing
+	// past them, come back towards where we started.
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	y := newx
+	for y >= 0 && y < len(rows) && !rows[y].selectable() {
+		y += step
+	}
+	if y >= 0 && y < len(rows) {
+		newx = y
+	} else {
+		for !rows[newx].selectable() {
+			newx -= step
+		}
+	}
+
+	t.SetActive(rows[newx])
+	return newx != x
+}
+
+// ScrollToActive makes sure the ActiveItem is a visible row and scrolls the view so that it
+// is on screen. If the active item has been hidden by closing one of its ancestors, or removed
+// from the tree, the nearest visible ancestor becomes active instead. This is called after
+// anything that changes the shape of the tree or the size of the view.
+func (t *Tree) ScrollToActive() {
+	rows := t.visibleItems()
+	if len(rows) == 0 {
+		t.ActiveItem = nil
+		t.viewtop = 0
+		t.ActiveLine = 0
+		return
+	}
+
+	x := rowIndex(rows, t.ActiveItem)
+	for ti := t.ActiveItem; x < 0 && ti != nil; {
+		par, ok := ti.Parent.(*TreeItem)
+		if !ok {
+			break
+		}
+		ti = par
+		x = rowIndex(rows, ti)
+	}
+	if x < 0 {
+		x = 0
+	}
+	t.ActiveItem = rows[x]
+
+	top := t.viewtop
+	if x < top {
+		top = x
+	}
+	if height := t.listHeight(); height > 0 && x >= top+height {
+		top = x - height + 1
+	}
+	t.setViewTop(top, len(rows))
+	t.ActiveLine = x - t.viewtop
+}
+
+// scrollBy moves the view without moving the cursor. ActiveLine may end up outside of 0..Height
+// when the active item has been scrolled off screen.
+func (t *Tree) scrollBy(n int) {
+	rows := t.visibleItems()
+	t.setViewTop(t.viewtop+n, len(rows))
+	if x := rowIndex(rows, t.ActiveItem); x >= 0 {
+		t.ActiveLine = x - t.viewtop
+	}
+}
+
+// setViewTop clamps the scroll position so we never scroll past the last row, leaving blank space
+// at the bottom of a view that could be showing items.
+func (t *Tree) setViewTop(top, nrows int) {
+	height := t.listHeight()
+	if height < 1 {
+		height = 1
+	}
+	if top > nrows-height {
+		top = nrows - height
+	}
+	if top < 0 {
+		top = 0
+	}
+	t.viewtop = top
+}
@@ -0,0 +1,267 @@
+package teatree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func item(name string, children ...*TreeItem) *TreeItem {
+	ti := NewItem(name, len(children) > 0, nil, nil, nil, nil, nil, nil, nil)
+	if len(children) > 0 {
+		ti.AddChildren(children...)
+	}
+	return ti
+}
+
+// newTodoTree builds the tree from the old SelectPrevious TODO, where moving up from Item 2
+// should land on SubSub 1 once everything is open:
+//
+//	Item 1
+//	  Sub 1
+//	    SubSub 1
+//	Item 2
+//	  AA
+//	  BB
+//	Item 3
+//	  CC
+func newTodoTree(height int) *Tree {
+	tr := New().(*Tree)
+	tr.Height = height
+	tr.AddChildren(
+		item("Item 1", item("Sub 1", item("SubSub 1"))),
+		item("Item 2", item("AA"), item("BB")),
+		item("Item 3", item("CC")),
+	)
+	return tr
+}
+
+func find(tr *Tree, path ...string) *TreeItem {
+	items := tr.Items
+	var found *TreeItem
+	for _, name := range path {
+		found = nil
+		for _, ti := range items {
+			if ti.Name == name {
+				found = ti
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		items = found.Children
+	}
+	return found
+}
+
+// openAll opens every item directly, without running any OpenFuncs
+func openAll(items []*TreeItem) {
+	for _, ti := range items {
+		if ti.CanHaveChildren {
+			ti.Open = true
+			ti.invalidate()
+			openAll(ti.Children)
+		}
+	}
+}
+
+// viewLines returns the names shown on each line of the rendered view
+func viewLines(tr *Tree) []string {
+	var names []string
+	for _, line := range strings.Split(tr.View(), "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, tr.OpenChildrenSymbol)
+		line = strings.TrimPrefix(line, tr.ClosedChildrenSymbol)
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names
+}
+
+func checkActiveOnScreen(t *testing.T, tr *Tree) {
+	t.Helper()
+	rows := tr.visibleItems()
+	x := rowIndex(rows, tr.ActiveItem)
+	if x < 0 {
+		t.Fatalf("active item %v is not a visible row", tr.ActiveItem)
+	}
+	if x < tr.viewtop || x >= tr.viewtop+tr.listHeight() {
+		t.Fatalf("active row %d is off screen (viewtop %d, height %d)", x, tr.viewtop, tr.listHeight())
+	}
+	if tr.ActiveLine != x-tr.viewtop {
+		t.Fatalf("ActiveLine is %d, expected %d", tr.ActiveLine, x-tr.viewtop)
+	}
+}
+
+func TestSelectPreviousIntoOpenSibling(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+
+	// Scroll so that Item 2 is the top line, then move up from it
+	tr.SetActive(find(tr, "Item 2"))
+	tr.ScrollUp(tr.viewtop)
+	tr.ScrollDown(3)
+	if got := viewLines(tr); got[0] != "Item 2" {
+		t.Fatalf("expected Item 2 at the top of the view, got %v", got)
+	}
+	tr.SelectPrevious()
+	if tr.ActiveItem.Name != "SubSub 1" {
+		t.Fatalf("expected SubSub 1, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+	if got := viewLines(tr); got[0] != "SubSub 1" {
+		t.Fatalf("expected the view to scroll up to SubSub 1, got %v", got)
+	}
+
+	tr.SelectNext()
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected Item 2, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestSelectAcrossTree(t *testing.T) {
+	tr := newTodoTree(4)
+	openAll(tr.Items)
+	want := []string{"Item 1", "Sub 1", "SubSub 1", "Item 2", "AA", "BB", "Item 3", "CC"}
+
+	for x, name := range want {
+		if tr.ActiveItem.Name != name {
+			t.Fatalf("down %d: expected %s, got %s", x, name, tr.ActiveItem.Name)
+		}
+		checkActiveOnScreen(t, tr)
+		tr.SelectNext()
+	}
+	// Trying to go past the end leaves us on the last row
+	if tr.ActiveItem.Name != "CC" || tr.ActiveLine != 3 {
+		t.Fatalf("expected CC on line 3, got %s on line %d", tr.ActiveItem.Name, tr.ActiveLine)
+	}
+	for x := len(want) - 1; x >= 0; x-- {
+		if tr.ActiveItem.Name != want[x] {
+			t.Fatalf("up %d: expected %s, got %s", x, want[x], tr.ActiveItem.Name)
+		}
+		checkActiveOnScreen(t, tr)
+		tr.SelectPrevious()
+	}
+	if tr.ActiveItem.Name != "Item 1" || tr.ActiveLine != 0 {
+		t.Fatalf("expected Item 1 on line 0, got %s on line %d", tr.ActiveItem.Name, tr.ActiveLine)
+	}
+}
+
+func TestCollapseMovesActiveToAncestor(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+	tr.SetActive(find(tr, "Item 1", "Sub 1", "SubSub 1"))
+
+	find(tr, "Item 1").ToggleChildren()
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected Item 1 to become active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestCollapseNearBottomScrollsBack(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+	tr.SelectLast()
+	checkActiveOnScreen(t, tr)
+
+	// Closing Item 3 leaves 7 rows, so the view should pull back up rather than leave a gap
+	find(tr, "Item 3").ToggleChildren()
+	checkActiveOnScreen(t, tr)
+	if got := viewLines(tr); len(got) != 3 || got[2] != "Item 3" {
+		t.Fatalf("expected a full view ending in Item 3, got %v", got)
+	}
+}
+
+func TestResizeKeepsActiveOnScreen(t *testing.T) {
+	tr := newTodoTree(8)
+	openAll(tr.Items)
+	tr.SelectLast()
+	checkActiveOnScreen(t, tr)
+
+	tr.Height = 2
+	tr.ScrollToActive()
+	checkActiveOnScreen(t, tr)
+	if got := viewLines(tr); len(got) != 2 || got[1] != "CC" {
+		t.Fatalf("expected the view to end in CC, got %v", got)
+	}
+}
+
+func TestRefreshRemovesActive(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+	tr.SetActive(find(tr, "Item 2", "BB"))
+
+	find(tr, "Item 2").Refresh()
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected Item 2 to become active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestInsertKeepsActiveOnScreen(t *testing.T) {
+	tr := newTodoTree(3)
+	openAll(tr.Items)
+	tr.SetActive(find(tr, "Item 1", "Sub 1", "SubSub 1"))
+
+	find(tr, "Item 1").AddChildren(item("Sub 2"))
+	tr.SelectNext()
+	if tr.ActiveItem.Name != "Sub 2" {
+		t.Fatalf("expected Sub 2, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+// newWideTree builds an open tree with n rows, in folders of 100 items
+func newWideTree(n int) *Tree {
+	tr := New().(*Tree)
+	tr.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	for x := 0; x < n/100; x++ {
+		folder := NewItem(fmt.Sprintf("folder %d", x), true, nil, nil, nil, nil, nil, nil, nil)
+		for y := 0; y < 99; y++ {
+			folder.AddChildren(NewItem(fmt.Sprintf("file %d", y), false, nil, nil, nil, nil, nil, nil, nil))
+		}
+		folder.OpenChildren()
+		tr.AddChildren(folder)
+	}
+	return tr
+}
+
+// The cost of a frame shouldn't depend on how big the tree is
+func BenchmarkView(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			tr := newWideTree(n)
+			tr.SelectLast()
+			tr.View()
+			b.ResetTimer()
+			for x := 0; x < b.N; x++ {
+				tr.View()
+			}
+		})
+	}
+}
+
+// Moving the cursor and redrawing shouldn't depend on how big the tree is either
+func BenchmarkKeyDown(b *testing.B) {
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			tr := newWideTree(n)
+			tr.SetActive(tr.Items[len(tr.Items)/2])
+			tr.View()
+			b.ResetTimer()
+			for x := 0; x < b.N; x++ {
+				if _, cmd := tr.Update(down); cmd != nil {
+					tr.SelectFirst()
+				}
+				tr.View()
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package teatree
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NameColumn is the column SortBy takes to sort by the items' names, rather than by one of the
+// tree's Columns
+const NameColumn = -1
+
+// SortBy sorts each group of siblings by one of the Columns, or by name for NameColumn. The
+// sort sticks, so children that are added or loaded later go into their place. Sorting by a
+// column takes over from the Less comparators, until ClearSort is called.
+func (t *Tree) SortBy(column int, descending bool) {
+	if column < NameColumn || column >= len(t.Columns) {
+		column = NameColumn
+	}
+	t.sorted = true
+	t.sortColumn = column
+	t.sortDescending = descending
+	t.Sort()
+}
+
+// ClearSort stops sorting by a column, and goes back to the order the Less comparators give.
+// Without any, the items are left in the order the column sort put them in.
+func (t *Tree) ClearSort() {
+	t.sorted = false
+	t.Sort()
+}
+
+// CycleSort moves on to the next way of sorting the tree. It starts with the names, then goes
+// through each column, first ascending and then descending, and then goes back to the order the
+// Less comparators give, (see ClearSort), before starting again.
+func (t *Tree) CycleSort() {
+	switch {
+	case !t.sorted:
+		t.SortBy(NameColumn, false)
+	case !t.sortDescending:
+		t.SortBy(t.sortColumn, true)
+	case t.sortColumn+1 < len(t.Columns):
+		t.SortBy(t.sortColumn+1, false)
+	default:
+		t.ClearSort()
+	}
+}
+
+// SortColumn returns the column the tree is sorted by. ok is false if it hasn't been sorted.
+func (t *Tree) SortColumn() (column int, descending, ok bool) {
+	return t.sortColumn, t.sortDescending, t.sorted
+}
+
+// Sort puts every group of siblings back in order. Children are put in their place as they are
+// added, so this is only needed when the order itself has changed, like after changing Less, or
+// the data the comparator looks at. The active item stays on the same line of the screen.
+func (t *Tree) Sort() {
+	t.holdActiveLine(func() {
+		t.sortItems(nil, t.Items)
+	})
+}
+
+// SortChildren puts the item's children, and everything below them, back in order
+func (ti *TreeItem) SortChildren() {
+	tree := ti.ParentTree
+	if tree == nil {
+		return
+	}
+	tree.holdActiveLine(func() {
+		tree.sortItems(ti, ti.Children)
+	})
+}
+
+// holdActiveLine makes a change that moves rows around, and then scrolls the view so that the
+// active item is back on the line it was on before
+func (t *Tree) holdActiveLine(change func()) {
+	line := t.ActiveLine
+	change()
+	t.Invalidate()
+	rows := t.visibleItems()
+	if x := rowIndex(rows, t.ActiveItem); x >= 0 {
+		t.setViewTop(x-line, len(rows))
+	}
+	t.ScrollToActive()
+}
+
+// lessFor returns the order the children of parent are kept in, (or the top level items, for a
+// nil parent), or nil if they are left in the order they were added. A column sort wins, then
+// the parent's own Less, and then the tree's.
+func (t *Tree) lessFor(parent *TreeItem) func(a, b *TreeItem) bool {
+	switch {
+	case t.sorted:
+		return t.columnLess
+	case parent != nil && parent.Less != nil:
+		return parent.Less
+	}
+	return t.Less
+}
+
+// columnLess is the order of a column sort
+func (t *Tree) columnLess(a, b *TreeItem) bool {
+	if t.sortDescending {
+		a, b = b, a
+	}
+	if t.sortColumn >= 0 && t.sortColumn < len(t.Columns) {
+		c := t.Columns[t.sortColumn]
+		if c.Less != nil {
+			return c.Less(a, b)
+		}
+		if c.Value != nil {
+			return NaturalLess(c.Value(a), c.Value(b))
+		}
+	}
+	return NaturalLess(a.Name, b.Name)
+}
+
+// sortItems sorts a group of siblings, and all of the groups below them
+func (t *Tree) sortItems(parent *TreeItem, items []*TreeItem) {
+	if less := t.lessFor(parent); less != nil {
+		sort.SliceStable(items, func(i, j int) bool {
+			return less(items[i], items[j])
+		})
+	}
+	for _, item := range items {
+		t.sortItems(item, item.Children)
+	}
+}
+
+// insertSortedMax is the most items insertSorted will insert one at a time
+const insertSortedMax = 16
+
+// insertSorted moves items[n:], which have just been appended to the sorted items[:n], into
+// their places. Items are often added one at a time, so this is much cheaper than sorting the
+// whole group again each time.
+func (t *Tree) insertSorted(parent *TreeItem, items []*TreeItem, n int) {
+	less := t.lessFor(parent)
+	if less != nil && len(items)-n > insertSortedMax {
+		// Too many to insert one at a time. The sort is stable, so this still puts new items
+		// after the ones already there that they tie with, the same as inserting them would.
+		t.sortItems(parent, items)
+		return
+	}
+	for ; n < len(items); n++ {
+		item := items[n]
+		t.sortItems(item, item.Children)
+		if less == nil {
+			continue
+		}
+		x := sort.Search(n, func(i int) bool {
+			return less(item, items[i])
+		})
+		copy(items[x+1:n+1], items[x:n])
+		items[x] = item
+	}
+}
+
+// ByName orders items by name, using NaturalLess
+func ByName(a, b *TreeItem) bool {
+	return NaturalLess(a.Name, b.Name)
+}
+
+// DirectoriesFirst puts the items that can have children ahead of the ones that can't, and
+// orders each of those with less, (or ByName if less is nil). For example:
+//
+//	tree.Less = teatree.DirectoriesFirst(nil)
+func DirectoriesFirst(less func(a, b *TreeItem) bool) func(a, b *TreeItem) bool {
+	if less == nil {
+		less = ByName
+	}
+	return func(a, b *TreeItem) bool {
+		if a.CanHaveChildren != b.CanHaveChildren {
+			return a.CanHaveChildren
+		}
+		return less(a, b)
+	}
+}
+
+// NaturalLess compares strings the way people expect, with runs of digits compared by their
+// value, so "file2" comes before "file10", and letters compared without regard to case, unless
+// that's all the strings differ by.
+func NaturalLess(a, b string) bool {
+	if c := naturalCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+func naturalCompare(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	for len(ra) > 0 && len(rb) > 0 {
+		if isDigit(ra[0]) && isDigit(rb[0]) {
+			var na, nb []rune
+			na, ra = digits(ra)
+			nb, rb = digits(rb)
+			if c := compareNumbers(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		ca, cb := unicode.ToLower(ra[0]), unicode.ToLower(rb[0])
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+		ra, rb = ra[1:], rb[1:]
+	}
+	return len(ra) - len(rb)
+}
+
+// digits splits the run of digits off the front of s
+func digits(s []rune) (number, rest []rune) {
+	x := 0
+	for x < len(s) && isDigit(s[x]) {
+		x++
+	}
+	return s[:x], s[x:]
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// compareNumbers compares two runs of digits by value, however long they are
+func compareNumbers(a, b []rune) int {
+	na := strings.TrimLeft(string(a), "0")
+	nb := strings.TrimLeft(string(b), "0")
+	if len(na) != len(nb) {
+		return len(na) - len(nb)
+	}
+	return strings.Compare(na, nb)
+}
@@ -0,0 +1,85 @@
+package teatree
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// joined returns the names of the items, separated by spaces
+func joined(items []*TreeItem) string {
+	return strings.Join(names(items), " ")
+}
+
+func TestNaturalLess(t *testing.T) {
+	got := []string{"file10", "File2", "file1", "file02b", "file2", "a", "file", "B", "file2a"}
+	sort.Slice(got, func(i, j int) bool { return NaturalLess(got[i], got[j]) })
+	want := "a B file file1 File2 file2 file2a file02b file10"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(got, " "))
+	}
+}
+
+func TestLessOnInsert(t *testing.T) {
+	tr := New().(*Tree)
+	tr.Less = DirectoriesFirst(nil)
+	tr.AddChildren(item("z.txt"), item("src", item("b10"), item("b9")), item("a.txt"), item("docs", item("x")))
+	if got := joined(tr.Items); got != "docs src a.txt z.txt" {
+		t.Fatalf("expected the directories first, got %s", got)
+	}
+	src := find(tr, "src")
+	if got := joined(src.Children); got != "b9 b10" {
+		t.Fatalf("expected the children of a new subtree to be sorted too, got %s", got)
+	}
+
+	// An item can have its own order
+	docs := find(tr, "docs")
+	docs.Less = func(a, b *TreeItem) bool { return NaturalLess(b.Name, a.Name) }
+	docs.AddChildren(item("y"), item("w"))
+	if got := joined(docs.Children); got != "y x w" {
+		t.Fatalf("expected the item's own Less to be used, got %s", got)
+	}
+}
+
+func TestSortKeepsActiveLine(t *testing.T) {
+	tr := newTodoTree(4)
+	openAll(tr.Items)
+	tr.SetActive(find(tr, "Item 2", "BB"))
+	tr.ScrollDown(2)
+	line := tr.ActiveLine
+
+	// Reverse everything, which moves BB to the other end of the tree
+	tr.Less = func(a, b *TreeItem) bool { return NaturalLess(b.Name, a.Name) }
+	tr.Sort()
+	if got := joined(tr.Items); got != "Item 3 Item 2 Item 1" {
+		t.Fatalf("expected the items reversed, got %s", got)
+	}
+	if tr.ActiveItem.Name != "BB" || tr.ActiveLine != line {
+		t.Fatalf("expected BB to stay on line %d, got %s on line %d", line, tr.ActiveItem.Name, tr.ActiveLine)
+	}
+	checkActiveOnScreen(t, tr)
+
+	// A column sort takes over from Less until it is cleared
+	tr.SortBy(NameColumn, false)
+	if got := joined(tr.Items); got != "Item 1 Item 2 Item 3" {
+		t.Fatalf("expected the names ascending, got %s", got)
+	}
+	tr.ClearSort()
+	if got := joined(tr.Items); got != "Item 3 Item 2 Item 1" {
+		t.Fatalf("expected Less to be back in charge, got %s", got)
+	}
+	checkActiveOnScreen(t, tr)
+}
+
+func TestSortKeyNeedsColumns(t *testing.T) {
+	tr := New().(*Tree)
+	tr.Less = DirectoriesFirst(nil)
+	tr.AddChildren(item("zeta"), item("alpha"), item("beta"))
+	tr.Update(keyMsg("s"))
+	if got := joined(tr.Items); got != "alpha beta zeta" {
+		t.Fatalf("expected the sort key to do nothing without Columns, got %s", got)
+	}
+	if _, _, ok := tr.SortColumn(); ok {
+		t.Fatal("expected the tree not to be sorted by a column")
+	}
+}
@@ -0,0 +1,137 @@
+package teatree
+
+// State is a snapshot of how the user has left a tree: which items are open and marked, where the
+// cursor is and how far the view is scrolled. Items are identified by their paths, (see GetPath),
+// so it can be encoded as JSON and restored into a tree built afresh the next time the program
+// runs.
+type State struct {
+	Open   [][]string `json:"open,omitempty"`   // The items that are open, parents before their children
+	Marked [][]string `json:"marked,omitempty"` // The items that are marked, in tree order
+	Active []string   `json:"active,omitempty"` // The active item
+	Top    int        `json:"top"`              // The row at the top of the view
+}
+
+// SaveState takes a snapshot of the tree's state, for RestoreState. Only the items that can be
+// seen count as open; an open item inside a closed one will be closed when it's restored.
+func (t *Tree) SaveState() State {
+	var s State
+	var walk func(items []*TreeItem, shown bool)
+	walk = func(items []*TreeItem, shown bool) {
+		for _, item := range items {
+			if item.Marked {
+				s.Marked = append(s.Marked, item.GetPath())
+			}
+			if shown && item.Open {
+				s.Open = append(s.Open, item.GetPath())
+			}
+			walk(item.Children, shown && item.Open)
+		}
+	}
+	walk(t.Items, true)
+
+	if active := t.ActiveItem; active != nil {
+		if active.placeholder != statusNone {
+			active = active.Parent.(*TreeItem)
+		}
+		s.Active = active.GetPath()
+	}
+	s.Top = t.viewtop
+	return s
+}
+
+// RestoreState puts back the state SaveState took, opening items on the way down to the ones
+// that were open, which runs their OpenFuncs. The children of closed items with marks inside them
+// are read too, but the items are left closed. Paths that no longer lead to an item are skipped.
+// Items with a LoadFunc are loaded in the background, and the rest of the state is put back as
+// their children arrive, unless the user presses a key or clicks first. Their commands are
+// returned by Update, or by FlushCmds if the tree is being set up outside of Update.
+func (t *Tree) RestoreState(s State) {
+	t.restoring = nil
+	t.applyState(&s)
+}
+
+// applyState puts back as much of the state as it can, remembering it if some of it is waiting
+// on a load. Applying it again puts back the rest.
+func (t *Tree) applyState(s *State) {
+	waiting := false
+	for _, path := range s.Open {
+		item, wait := t.lookup(path, lookupOpen)
+		if item != nil {
+			item.openChildren()
+		}
+		waiting = waiting || wait
+	}
+	for _, path := range s.Marked {
+		item, wait := t.lookup(path, lookupLoad)
+		if item != nil {
+			item.Marked = true
+		}
+		waiting = waiting || wait
+	}
+	active, wait := t.lookup(s.Active, lookupFind)
+	waiting = waiting || wait
+	if active != nil {
+		t.ActiveItem = active
+	}
+	t.setViewTop(s.Top, len(t.visibleItems()))
+	t.ScrollToActive()
+
+	t.restoring = nil
+	if waiting {
+		t.restoring = s
+	}
+}
+
+// lookupMode is what lookup does with the items on the way down to the one it's looking for
+type lookupMode int
+
+const (
+	lookupFind lookupMode = iota // Leave them alone, so only children that are there are found
+	lookupOpen                   // Open them
+	lookupLoad                   // Read their children if they haven't been, but leave them closed
+)
+
+// lookup finds the item at path, doing what mode says with the items on the way down to it. wait
+// is set if the path leads into an item whose children are still loading.
+func (t *Tree) lookup(path []string, mode lookupMode) (found *TreeItem, wait bool) {
+	items := t.Items
+	for _, name := range path {
+		if found != nil {
+			switch {
+			case mode == lookupOpen:
+				found.openChildren()
+			case mode == lookupLoad && !found.Open && found.unread():
+				// Opening it reads the children, and closing it again leaves them there, (or
+				// in the case of a LoadFunc, they arrive later and state is applied again)
+				found.openChildren()
+				found.ToggleChildren()
+			}
+			if found.loading {
+				return nil, true
+			}
+			items = found.Children
+		}
+		found = nil
+		for _, item := range items {
+			if item.Name == name {
+				found = item
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+	}
+	return found, false
+}
+
+// unread reports whether an item's children haven't been read by its LoadFunc or OpenFunc yet
+func (ti *TreeItem) unread() bool {
+	switch {
+	case ti.LoadFunc != nil:
+		return !ti.loaded && !ti.loading
+	case ti.OpenFunc != nil:
+		return len(ti.Children) == 0
+	}
+	return false
+}
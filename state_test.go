@@ -0,0 +1,134 @@
+package teatree
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSaveRestoreState(t *testing.T) {
+	tr := newTodoTree(4)
+	openAll(tr.Items)
+	find(tr, "Item 2").ToggleChildren() // Closed, with a mark inside
+	find(tr, "Item 2", "AA").Marked = true
+	find(tr, "Item 1").Marked = true
+	tr.SetActive(find(tr, "Item 3", "CC"))
+	tr.SelectPrevious() // The view stays scrolled to the bottom
+
+	data, err := json.Marshal(tr.SaveState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Item 1"}, {"Item 1", "Sub 1"}, {"Item 3"}}
+	if !reflect.DeepEqual(s.Open, want) {
+		t.Fatalf("expected the open items %v, got %v", want, s.Open)
+	}
+
+	restored := newTodoTree(4)
+	restored.RestoreState(s)
+	if !reflect.DeepEqual(viewLines(restored), viewLines(tr)) {
+		t.Fatalf("expected the same view, got %v instead of %v", viewLines(restored), viewLines(tr))
+	}
+	if restored.ActiveItem != find(restored, "Item 3") || restored.viewtop != tr.viewtop {
+		t.Fatalf("expected Item 3 active with the view at %d, got %s at %d", tr.viewtop, restored.ActiveItem.Name, restored.viewtop)
+	}
+	if !find(restored, "Item 2", "AA").Marked || !find(restored, "Item 1").Marked {
+		t.Fatal("expected the marks to be restored")
+	}
+}
+
+func TestRestoreStateOpensLazily(t *testing.T) {
+	listing := map[string][]string{"dir": {"a", "b"}, "b": {"x", "y"}}
+	tr := newTodoTree(20)
+	tr.AddChildren(newListedDir("dir", listing))
+	s := State{
+		Open:   [][]string{{"dir"}, {"dir", "b"}, {"dir", "gone"}, {"nowhere", "else"}},
+		Active: []string{"dir", "b", "y"},
+	}
+	tr.RestoreState(s)
+	if tr.ActiveItem != find(tr, "dir", "b", "y") {
+		t.Fatalf("expected y to be loaded and made active, got %s", tr.ActiveItem.Name)
+	}
+	checkActiveOnScreen(t, tr)
+
+	// Whatever has gone since is skipped
+	tr = newTodoTree(20)
+	delete(listing, "b")
+	listing["dir"] = []string{"a", "b"}
+	tr.AddChildren(newListedDir("dir", listing))
+	tr.RestoreState(s)
+	if !find(tr, "dir").Open || tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected dir open and the cursor left alone, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestRestoreStateWaitsForLoads(t *testing.T) {
+	newLazy := func() *TreeItem {
+		lazy := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+		lazy.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) {
+			return []*TreeItem{item("one"), item("two")}, nil
+		})
+		return lazy
+	}
+	tr := newTodoTree(20)
+	tr.AddChildren(newLazy())
+	tr.RestoreState(State{
+		Open:   [][]string{{"lazy"}},
+		Marked: [][]string{{"lazy", "one"}},
+		Active: []string{"lazy", "two"},
+	})
+	if !find(tr, "lazy").Loading() || tr.ActiveItem.Name != "Item 1" {
+		t.Fatal("expected the cursor to wait for the children to load")
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if tr.ActiveItem != find(tr, "lazy", "two") || !find(tr, "lazy", "one").Marked {
+		t.Fatalf("expected the rest of the state once the children arrived, got %s active", tr.ActiveItem.Name)
+	}
+	if tr.restoring != nil {
+		t.Fatal("expected nothing left to restore")
+	}
+
+	// Pressing a key first leaves the cursor where the user put it
+	tr = newTodoTree(20)
+	tr.AddChildren(newLazy())
+	tr.RestoreState(State{Open: [][]string{{"lazy"}}, Active: []string{"lazy", "two"}})
+	cmd := tr.FlushCmds()
+	tr.Update(keyMsg("j"))
+	deliver(t, tr, cmd)
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected the user's move to stick, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestRestoreStateMarksInsideClosed(t *testing.T) {
+	listing := map[string][]string{"dir": {"a", "b"}, "b": {"x", "y"}}
+	tr := newTodoTree(20)
+	dir := newListedDir("dir", listing)
+	lazy := NewItem("lazy", true, nil, nil, nil, nil, nil, nil, nil)
+	lazy.LoadFunc = AsyncLoader(func(*TreeItem) ([]*TreeItem, error) {
+		return []*TreeItem{item("one"), item("two")}, nil
+	})
+	tr.AddChildren(dir, lazy)
+	tr.RestoreState(State{
+		Open:   [][]string{{"dir"}},
+		Marked: [][]string{{"dir", "b", "x"}, {"lazy", "two"}},
+	})
+	b := find(tr, "dir", "b")
+	if b.Open || !find(tr, "dir", "b", "x").Marked {
+		t.Fatal("expected x to be marked, with b left closed")
+	}
+	if lazy.Open || !lazy.Loading() {
+		t.Fatal("expected lazy to be loading, but closed")
+	}
+	deliver(t, tr, tr.FlushCmds())
+	if lazy.Open || !find(tr, "lazy", "two").Marked {
+		t.Fatal("expected two to be marked once it arrived, with lazy left closed")
+	}
+	if tr.restoring != nil {
+		t.Fatal("expected nothing left to restore")
+	}
+}
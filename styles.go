@@ -0,0 +1,103 @@
+package teatree
+
+import "github.com/charmbracelet/lipgloss"
+
+// Styles holds everything a tree is drawn with. Each tree has its own copy, so several trees in
+// one program can look different. Change the fields of Tree.Styles, or replace it with your own.
+type Styles struct {
+	Cursor        lipgloss.Style // the active row, while the tree has focus
+	BlurredCursor lipgloss.Style // the active row, while the tree is blurred
+	Row           lipgloss.Style // every other row
+	Chevron       lipgloss.Style // the open/closed symbol in front of items that can have children
+	IndentGuide   lipgloss.Style // the lines that join children to their parent
+	Marked        lipgloss.Style // items marked in a MultiSelect tree, on top of Row or Cursor
+	Disabled      lipgloss.Style // text that can't be acted on, like loading rows and hints
+	Error         lipgloss.Style // a failed load
+	FilterMatch   lipgloss.Style // the characters of a label that matched the filter
+	Border        lipgloss.Style // the colors of the border, when Tree.Style has one
+	Title         lipgloss.Style // Tree.Title, drawn above the rows
+	Header        lipgloss.Style // the column titles of a tree-table
+}
+
+// DefaultStyles returns the styles a new tree starts with
+func DefaultStyles() Styles {
+	return Styles{
+		Cursor: lipgloss.NewStyle().
+			Background(lipgloss.Color("62")),
+		BlurredCursor: lipgloss.NewStyle().
+			Background(lipgloss.Color("238")),
+		Row:     lipgloss.NewStyle(),
+		Chevron: lipgloss.NewStyle(),
+		IndentGuide: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240")),
+		Marked: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("212")),
+		Disabled: lipgloss.NewStyle().
+			Faint(true),
+		Error: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("9")),
+		FilterMatch: lipgloss.NewStyle().
+			Underline(true).
+			Foreground(lipgloss.Color("212")),
+		Border: lipgloss.NewStyle().
+			BorderForeground(lipgloss.Color("62")),
+		Title: lipgloss.NewStyle().
+			Bold(true).
+			Padding(0, 1),
+		Header: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("245")),
+	}
+}
+
+// frameStyle is Tree.Style, with the border colored by Styles.Border unless Tree.Style has its own
+func (t *Tree) frameStyle() lipgloss.Style {
+	return t.Style.Copy().Inherit(t.Styles.Border)
+}
+
+// frame wraps the rendered rows in Tree.Style, sized so that the whole thing fills Width and Height
+func (t *Tree) frame(s string) string {
+	style := t.frameStyle()
+	if style.GetHorizontalFrameSize() == 0 && style.GetVerticalFrameSize() == 0 {
+		return style.Render(s)
+	}
+	if w := t.innerWidth(); w > 0 {
+		s = lipgloss.NewStyle().MaxWidth(w).Render(s)
+		style = style.Width(w + style.GetHorizontalPadding())
+	}
+	if h := t.innerHeight(); h > 0 {
+		style = style.Height(h + style.GetVerticalPadding())
+	}
+	return style.Render(s)
+}
+
+// innerWidth is the width left for the rows once Tree.Style's border, margin and padding are taken
+// off. Like Width, it is 0 if the tree hasn't been given a size.
+func (t *Tree) innerWidth() int {
+	return inner(t.Width, t.Style.GetHorizontalFrameSize())
+}
+
+// innerHeight is innerWidth for Height
+func (t *Tree) innerHeight() int {
+	return inner(t.Height, t.Style.GetVerticalFrameSize())
+}
+
+func inner(size, frame int) int {
+	if size <= 0 {
+		return size
+	}
+	if size -= frame; size > 0 {
+		return size
+	}
+	return 1
+}
+
+// frameLeft and frameTop are where the rows start, relative to OffsetX and OffsetY
+func (t *Tree) frameLeft() int {
+	return t.Style.GetMarginLeft() + t.Style.GetBorderLeftSize() + t.Style.GetPaddingLeft()
+}
+
+func (t *Tree) frameTop() int {
+	return t.Style.GetMarginTop() + t.Style.GetBorderTopSize() + t.Style.GetPaddingTop()
+}
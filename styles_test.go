@@ -0,0 +1,74 @@
+package teatree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestStyleFramesView(t *testing.T) {
+	tr := newTodoTree(6)
+	openAll(tr.Items)
+	tr.Width = 20
+	tr.Style = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+
+	// The border takes a line off the top and bottom, which leaves room for 4 rows
+	lines := strings.Split(tr.View(), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected the frame to be 6 lines high, got %d:\n%s", len(lines), tr.View())
+	}
+	for x, line := range lines {
+		if w := lipgloss.Width(line); w != 20 {
+			t.Fatalf("expected line %d to be 20 wide, got %d: %q", x, w, line)
+		}
+	}
+	if got := viewLines(tr); !strings.Contains(got[4], "Item 2") {
+		t.Fatalf("expected the last row to be Item 2, got %q", got[4])
+	}
+
+	// Clicks are offset by the border and padding
+	tr.Update(click(10, 2))
+	if tr.ActiveItem.Name != "Sub 1" {
+		t.Fatalf("expected the second row inside the frame to be Sub 1, got %s", tr.ActiveItem.Name)
+	}
+	sub := tr.ActiveItem
+	tr.Update(click(4, 2))
+	if sub.Open {
+		t.Fatal("expected a click on Sub 1's chevron to close it")
+	}
+
+	tr.SelectLast()
+	checkActiveOnScreen(t, tr)
+}
+
+func TestTitle(t *testing.T) {
+	tr := newTodoTree(4)
+	tr.Title = "Todo"
+
+	if got := viewLines(tr); len(got) != 4 || got[0] != "Todo" || got[1] != "Item 1" {
+		t.Fatalf("expected the title above 3 rows, got %v", got)
+	}
+	tr.Update(click(8, 1))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected a click under the title to select Item 1, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestStylesArePerTree(t *testing.T) {
+	a := newTodoTree(4)
+	b := newTodoTree(4)
+	a.Styles.Cursor = a.Styles.Cursor.Copy().Background(lipgloss.Color("1"))
+	if b.Styles.Cursor.GetBackground() == a.Styles.Cursor.GetBackground() {
+		t.Fatal("expected changing one tree's styles to leave the other alone")
+	}
+
+	// Drawing an item with its own colors mustn't leak them into the tree's styles
+	red := func(*TreeItem) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color("9")) }
+	a.ActiveItem.labelStyle = red
+	a.ActiveItem.iconStyle = red
+	a.View()
+	if _, ok := a.Styles.Cursor.GetForeground().(lipgloss.NoColor); !ok {
+		t.Fatalf("expected the cursor style to be untouched, it has foreground %v", a.Styles.Cursor.GetForeground())
+	}
+}
@@ -1,11 +1,14 @@
 package teatree
 
 import (
-	"log"
-	"strings"
+	"context"
 	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -26,22 +29,6 @@ type ItemHolder interface {
 	Refresh() // This tells the item holder to delete all of its children and re-read them.
 }
 
-// Styling
-
-var (
-	unfocusedStyle = lipgloss.NewStyle()
-	// Border(lipgloss.HiddenBorder()).
-	// BorderTop(false).
-	// BorderBottom(false)
-	focusedStyle = lipgloss.NewStyle().
-		//Border(lipgloss.RoundedBorder()).
-		//BorderTop(false).
-		//BorderBottom(false).
-		Background(lipgloss.Color("62")).
-		BorderForeground(lipgloss.Color("62"))
-	//Background(lipgloss.Color("#FFFFFF"))
-)
-
 type TreeItem struct {
 	sync.Mutex
 	ParentTree      *Tree
@@ -50,9 +37,25 @@ type TreeItem struct {
 	Children        []*TreeItem
 	CanHaveChildren bool // CanHaveChildren: By setting this to True, you say that this item can have children. This allows for the implementation of a lazy loader, when you supply an Open() function. This affects how the item is rendered.
 	Open            bool
+	Marked          bool // Marked is set when the user has picked this item in a multi-select tree
+	check           CheckState
 	Data            interface{}
 	OpenFunc        func(*TreeItem)
 	CloseFunc       func(*TreeItem)
+	Less            func(a, b *TreeItem) bool      // Less, if set, orders the item's children instead of the tree's Less
+	LoadFunc        func(*TreeItem) tea.Cmd        // LoadFunc, if set, is used instead of OpenFunc to load the children in the background the first time the item is opened. Its command produces a ChildrenLoadedMsg, see AsyncLoader.
+	loaded          bool                           // LoadFunc has delivered the children
+	loading         bool                           // LoadFunc is running
+	reloading       bool                           // the load that is running is a Reload, so its children are matched up with the ones already here
+	loadCancel      context.CancelFunc             // cancels the Provider load that is running
+	loadErr         error                          // LoadFunc failed, shown inline until retried
+	loadGen         int                            // bumped for each load, so results overtaken by a refresh are dropped
+	statusRow       *TreeItem                      // the loading or error row shown in place of the children
+	remaining       int                            // children that haven't been paged in yet
+	row             int                            // index into the tree's visible rows, only meaningful when the rows say so
+	rowDepth        int                            // how deep the row is, worked out with the rows
+	rowLast         bool                           // the row is the last one drawn under its parent, for the indent guides
+	placeholder     rowStatus                      // if this isn't a real item, but a loading or error row
 	icon            func(*TreeItem) string         // Function returns what the icon should be.
 	labelStyle      func(*TreeItem) lipgloss.Style // Function returns the style for the label, intended for color
 	iconStyle       func(*TreeItem) lipgloss.Style // Function returns the style for the icon, intended for color
@@ -94,6 +97,12 @@ func (ti *TreeItem) Init() tea.Cmd {
 func (ti *TreeItem) Refresh() {
 	ti.Children = []*TreeItem{}
 	ti.Open = false
+	ti.invalidate()
+	ti.loaded = false
+	if ti.ParentTree != nil {
+		ti.ParentTree.cancelLoad(ti)
+		ti.ParentTree.ScrollToActive()
+	}
 }
 
 func (ti *TreeItem) GetItems() []*TreeItem {
@@ -101,199 +110,64 @@ func (ti *TreeItem) GetItems() []*TreeItem {
 }
 
 // SelectPrevious - this is being invoked on a TreeItem that is currently selected and the
-// user wants to move up to the previous selection. This will involve recursively going up the
-// tree until we find the one to select or we get to the top
-//
-// TODO: SelectPrevious() does not work in the following situation:
-//
-// 󰅀 Item 1
-//    Sub 1
-//      SubSub 1   **TOHERE
-// 󰅀 Item 2	**FROMHERE
-//    AA
-//    BB
-// 󰅂 Item 3
-
+// user wants to move up to the previous visible row, which may be the last open descendant of
+// the previous sibling, or our parent.
 func (ti *TreeItem) SelectPrevious() {
-
-	// User has pressed 'up'. If we're at the top of the "view", then we want to try and scroll up by one
-	// We do this by checking the viewtop value. If it is > 0, then the view has scrolled down, so we
-	// can decrement it by one.
-
-	tree := ti.ParentTree
-	atTop := false
-
-	if tree.ActiveLine == 0 {
-		atTop = true
-	} else {
-		tree.ActiveLine -= 1
-	}
-
-	siblingItems := ti.Parent.GetItems()
-
-	for x, item := range siblingItems {
-		if item == ti {
-			// Check to see if there is a previous one in the current list
-			if x-1 >= 0 {
-				newItem := siblingItems[x-1]
-				// We want the next one "up". This could be the previous sibling, or if that sibling is open, it could be one of the siblings's children
-				for {
-					// Can this item have children?
-					if newItem.CanHaveChildren && newItem.Open && len(newItem.Children) > 0 {
-						// If so, we want to check again with the last one in this list. This could
-						// be a many level tree, and we always want to check the last unopened child
-						lastKid := len(newItem.Children) - 1
-						// Descend into this child and iterate through checking this one
-						newItem = newItem.Children[lastKid]
-					} else {
-						if atTop {
-							tree.ScrollUp(1)
-						}
-						item.ParentTree.SetActive(newItem)
-						return
-					}
-
-					//item.ParentTree.ActiveItem = siblingItems[x-1]
-				}
-			} else {
-				// Nope, we were at the top. So now we just activate our Parent
-				// Just make sure the parent is an item and not the tree. If it's the tree,
-				// then we stop
-				par, ok := ti.Parent.(*TreeItem)
-				if ok {
-					if atTop {
-						tree.ScrollUp(1)
-					}
-					item.ParentTree.SetActive(par)
-				}
-			}
-			return
-		}
+	if ti.ParentTree != nil {
+		ti.ParentTree.moveFrom(ti, -1)
 	}
 }
 
-// We're being told to select the next item relative to our current position.
+// SelectNext - we're being told to select the next visible row relative to our current position.
+// This is either our first child if we're open, the next sibling, or the next sibling of the
+// nearest ancestor that has one.
 func (ti *TreeItem) SelectNext() {
-	// See if we need to scroll first:
-
-	tree := ti.ParentTree
-
-	atBottom := false
-	windowBottom := tree.Height
-
-	if tree.ActiveLine == windowBottom-1 {
-		atBottom = true
-	} else {
-		tree.ActiveLine += 1
-	}
-	descend := true
-	for {
-		parentItems := ti.Parent.GetItems()
-
-		// Check to see if we are going down into a child. If so, select the first child
-		if len(ti.Children) > 0 && ti.Open && descend {
-			ti.ParentTree.SetActive(ti.Children[0])
-			if atBottom {
-				tree.ScrollDown(1)
-			}
-			return
-		}
-
-		// If we're not descending into one of our own children, then we are going to the next
-		// sibling item to us
-		for x, item := range parentItems {
-			// Find the current item
-			if item == ti {
-				if x+1 < len(parentItems) {
-					// Select the sibling if there is one
-					ti.ParentTree.SetActive(parentItems[x+1])
-					if atBottom {
-						tree.ScrollDown(1)
-					}
-					// If there is no sibling, then we are at the end and just do nothing
-					return
-				}
-			}
-		}
-		if ti.Parent == ti.ParentTree {
-			// We've hit the top
-			return
-		}
-		// To get here, the user has tried to go past the end of the current list of children.
-		// So we now need to tell our parent to choose a sibling. We turn off descent because
-		// we aren't here going into an item, because we've already gone beyond the end of a list
-		descend = false
-		ti = ti.Parent.(*TreeItem)
+	if ti.ParentTree != nil {
+		ti.ParentTree.moveFrom(ti, 1)
 	}
 }
 
-func (ti *TreeItem) ViewScrolled(viewtop, curline, bottomline int) (int, string) {
-	// Return the view string for myself plus my children if I am open
-	var pre_s string
-	for x := 0; x < ti.indent; x++ {
-		pre_s += "  "
+// renderRow draws the single line of the tree for this item: indent, chevron, icon and label
+func (ti *TreeItem) renderRow() string {
+	if ti.placeholder != statusNone {
+		return ti.renderStatusRow()
 	}
+	tree := ti.ParentTree
+	pre_s := ti.guides()
 	if ti.CanHaveChildren {
-		if ti.Open {
-			pre_s += ChevronDown
-		} else {
-			pre_s += ChevronRight
+		expanded := ti.Open
+		if tree.filterShown != nil {
+			expanded = len(tree.displayedChildren(ti)) > 0
 		}
+		pre_s += tree.chevron(true, expanded)
 	} else {
-		pre_s += NoChevron
+		pre_s += tree.chevron(false, false)
 	}
 
-	ai := ti.ParentTree.ActiveItem
-
-	var s string
-	render := true
-	if curline < 0 {
-		render = false
+	// Inherit writes into the style it's called on, so everything here works on copies
+	var baseline lipgloss.Style
+	if tree.ActiveItem == ti {
+		baseline = tree.cursorStyle().Copy()
+	} else {
+		baseline = tree.Styles.Row.Copy()
 	}
-
-	if render {
-		var baseline lipgloss.Style
-		if ai != nil && ai == ti {
-			baseline = focusedStyle
-		} else {
-			baseline = unfocusedStyle
-		}
-		istyle := baseline.Inherit(ti.IconStyle())
-		lstyle := baseline.Inherit(ti.LabelStyle())
-		s = pre_s + istyle.Render(s+ti.Icon()) + baseline.Render(" ") + lstyle.Render(ti.Name)
+	if ti.Marked {
+		baseline = tree.Styles.Marked.Copy().Inherit(baseline)
 	}
-
-	curline += 1
-
-	if curline > bottomline {
-		return curline, ""
+	istyle := baseline.Copy().Inherit(ti.IconStyle())
+	lstyle := baseline.Copy().Inherit(ti.LabelStyle())
+	label := lstyle.Render(ti.Name)
+	if matched, ok := tree.filterMatches[ti]; ok && tree.FilterValue == nil {
+		label = lipgloss.StyleRunes(ti.Name, matched, tree.Styles.FilterMatch.Copy().Inherit(lstyle), lstyle)
 	}
-
-	if len(ti.Children) > 0 && ti.Open {
-		var kids []string
-		for _, item := range ti.Children {
-			item.indent = ti.indent + 1
-			var tmps string
-			curline, tmps = item.ViewScrolled(viewtop, curline, bottomline)
-			if strings.TrimSpace(tmps) != "" {
-				kids = append(kids, tmps)
-			}
-			if curline >= bottomline {
-				break
-			}
-		}
-		var composite []string
-		if s != "" {
-			composite = append(composite, s)
-		}
-
-		composite = append(composite, kids...)
-		s = lipgloss.JoinVertical(
-			lipgloss.Left,
-			composite...,
-		)
+	if tree.Checkboxes {
+		pre_s += ti.check.checkbox() + " "
 	}
-	return curline, s
+	row := pre_s + istyle.Render(ti.Icon()) + baseline.Render(" ") + label
+	if len(tree.Columns) > 0 {
+		return tree.renderCells(ti, row, baseline)
+	}
+	return row
 }
 
 func (ti *TreeItem) View() string {
@@ -302,24 +176,16 @@ func (ti *TreeItem) View() string {
 	for x := 0; x < ti.indent; x++ {
 		s += "  "
 	}
-	if ti.CanHaveChildren {
-		if ti.Open {
-			s += ChevronDown
-		} else {
-			s += ChevronRight
-		}
-	} else {
-		s += NoChevron
-	}
+	s += ti.ParentTree.chevron(ti.CanHaveChildren, ti.Open)
 
 	ai := ti.ParentTree.ActiveItem
 
 	if ai != nil && ai == ti {
 		// If this is the active item, then we should be highlit
-		s = focusedStyle.Render(s + ti.Icon() + " " + ti.Name)
+		s = ti.ParentTree.cursorStyle().Render(s + ti.Icon() + " " + ti.Name)
 	} else {
 		//s += ti.Icon + " " + ti.Name
-		s = unfocusedStyle.Render(s + ti.Icon() + " " + ti.Name)
+		s = ti.ParentTree.Styles.Row.Render(s + ti.Icon() + " " + ti.Name)
 	}
 
 	if len(ti.Children) > 0 && ti.Open {
@@ -350,17 +216,22 @@ func (ti *TreeItem) GetPath() []string {
 
 func (ti *TreeItem) OpenChildren() {
 	ti.Open = true
+	ti.invalidate()
 }
 
 func (ti *TreeItem) CloseChildren() {
 	ti.Open = false
+	ti.invalidate()
 }
 
 func (ti *TreeItem) ToggleChildren() {
 	if ti.CanHaveChildren {
 		ti.Open = !ti.Open
+		ti.invalidate()
 		if ti.Open {
-			if ti.OpenFunc != nil {
+			if ti.LoadFunc != nil && !ti.loaded && ti.ParentTree != nil {
+				ti.ParentTree.startLoad(ti)
+			} else if ti.OpenFunc != nil {
 				ti.OpenFunc(ti)
 			}
 		} else {
@@ -368,6 +239,9 @@ func (ti *TreeItem) ToggleChildren() {
 				ti.CloseFunc(ti)
 			}
 		}
+		if ti.ParentTree != nil {
+			ti.ParentTree.ScrollToActive()
+		}
 	}
 }
 
@@ -375,18 +249,19 @@ func (ti *TreeItem) ToggleChildren() {
 // AddChild - adds a child item to the item. Adding a child will result in the automatic inclusion of
 // the collapse chevron
 func (ti *TreeItem) AddChildren(children ...*TreeItem) ItemHolder {
-	// TODO: Should this do any mutex
-	ti.Lock()
-	ti.Children = append(ti.Children, children...)
-	ti.Unlock()
-	ti.CanHaveChildren = true // If it wasn't set before, it will be now
+	return ti.InsertAt(len(ti.Children), children...)
+}
 
-	for _, child := range children {
-		child.Parent = ti
-		child.ParentTree = ti.ParentTree
+// setParentTree points the item and all of its descendants at the tree. Subtrees are often built
+// before being added to the tree, so their children won't know which tree they're in yet.
+func (ti *TreeItem) setParentTree(t *Tree) {
+	ti.ParentTree = t
+	if ti.statusRow != nil {
+		ti.statusRow.ParentTree = t
+	}
+	for _, child := range ti.Children {
+		child.setParentTree(t)
 	}
-
-	return ti
 }
 
 func NewItem(name string, canHaveChildren bool, children []*TreeItem, icon func(*TreeItem) string, labelStyle, iconStyle func(*TreeItem) lipgloss.Style, openFunc, closeFunc func(*TreeItem), data interface{}) *TreeItem {
@@ -415,26 +290,91 @@ type KeyMap struct {
 	Back     key.Binding
 	Open     key.Binding
 	Select   key.Binding
+	Retry    key.Binding // Retry loading the children of an item whose LoadFunc failed
+	Sort     key.Binding // Sort cycles through sorting by name and by each of the tree's Columns, if it has any
+
+	// Marking, only used when the tree is MultiSelect
+	MarkUp       key.Binding
+	MarkDown     key.Binding
+	MarkChildren key.Binding
+
+	// Filtering
+	Filter       key.Binding
+	ClearFilter  key.Binding
+	AcceptFilter key.Binding
+
+	Help key.Binding // Shows the help overlay, if the tree's HelpOverlay is set
 }
 
 type Tree struct {
 	sync.Mutex
-	viewtop              int // for scrolling
+	viewtop              int // for scrolling: the index into the visible rows of the first line on screen
 	Width                int
 	Height               int
-	ClosedChildrenSymbol string
-	OpenChildrenSymbol   string
+	ClosedChildrenSymbol string // drawn in front of closed items, see SetGlyphs
+	OpenChildrenSymbol   string // drawn in front of open items
+	Glyphs               Glyphs // the glyph set the tree was given, see DetectGlyphs
 	ActiveItem           *TreeItem
-	ActiveLine           int // Which line, (from 0..Height) is the cursor on?
+	ActiveLine           int // Which line, (from 0..Height) is the cursor on? This is derived from viewtop, see ScrollToActive
 	Items                []*TreeItem
 	initialized          bool
-	Style                lipgloss.Style
+	Style                lipgloss.Style            // Style frames the whole tree. Its border, margin and padding are drawn inside Width and Height.
+	Styles               Styles                    // Styles are what the rows are drawn with, see DefaultStyles
+	Title                string                    // Title, if set, is drawn above the rows
+	IndentGuides         bool                      // IndentGuides draws lines joining children to their parents, like the tree command does
+	IndentWidth          int                       // How many columns each level is indented by, DefaultIndentWidth if not set
+	Less                 func(a, b *TreeItem) bool // Less, if set, keeps each group of siblings in order as they are added. See DirectoriesFirst and NaturalLess.
+	Columns              []Column                  // Columns, if there are any, turn the tree into a tree-table
+	NameTitle            string                    // The header of the tree's own column in a tree-table, DefaultNameTitle if not set
+	KeyFunc              func(*TreeItem) string    // KeyFunc identifies an item from one load to the next, so Reload can tell which items are still there. The Name is used if it is nil.
 	KeyMap               KeyMap
+	SelectAction         SelectAction // What the Select key does, defaults to toggling the item and sending an ItemSelectedMsg
+	ChildPageSize        int          // How many children a PagedLoader fetches at a time, DefaultChildPageSize if not set
+	MultiSelect          bool         // When set, Space marks and unmarks items instead of opening and closing them
+	Checkboxes           bool         // When set, each item has a tri-state checkbox and Space checks and unchecks items
+	OffsetX              int          // Screen column of the left edge of the tree, used to map mouse clicks onto items
+	OffsetY              int          // Screen row of the top of the tree, used to map mouse clicks onto items
+	lastClick            time.Time    // for detecting double clicks
+	lastClickItem        *TreeItem
+	markAnchor           *TreeItem // where a shift-click range of marks starts from
+	focus                bool
+	sorted               bool // set once the tree has been sorted, see SortBy
+	sortColumn           int
+	sortDescending       bool
+	restoring            *State          // what's left of a RestoreState that is waiting on loads
+	provider             Provider        // where the items come from, if the tree was filled by SetProvider
+	providerCtx          context.Context // what the Provider's loads are derived from
+	providerGen          int             // counts SetProvider calls, so a Reload from an old Provider is dropped
+
+	// FilterValue returns the text the filter matches against. If it is nil, the item's Name is
+	// used and the matched characters are highlighted.
+	FilterValue      func(*TreeItem) string
+	filterState      FilterState
+	filterInput      textinput.Model
+	filterMatches    map[*TreeItem][]int // rune indexes of the matched characters, keyed by matching item
+	filterShown      map[*TreeItem]bool  // the matching items plus all of their ancestors
+	filterPrevActive *TreeItem           // what was active before filtering, in case nothing matches
+
+	// HelpOverlay, when set, lets the Help key show a list of the bindings over the tree. It is on
+	// by default. AdditionalShortHelpKeys and AdditionalFullHelpKeys let the host add its own
+	// bindings to the tree's help, so they show up in the overlay and in a help.Model.
+	HelpOverlay             bool
+	AdditionalShortHelpKeys func() []key.Binding
+	AdditionalFullHelpKeys  func() []key.Binding
+	helpView                help.Model
+	showingHelp             bool
+
+	rows          []*TreeItem // cached visible rows, see visibleItems
+	rowsValid     bool
+	pending       []tea.Cmd // commands started outside of Update, like background loads
+	spinner       spinner.Model
+	spinning      bool
+	loadsInFlight int
 }
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Space:    key.NewBinding(key.WithKeys(" "), key.WithHelp(" ", "space")),
+		Space:    key.NewBinding(key.WithKeys(" ", "."), key.WithHelp("space", "toggle")),
 		GoToTop:  key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "first")),
 		GoToLast: key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "last")),
 		Down:     key.NewBinding(key.WithKeys("j", "down", "ctrl+n"), key.WithHelp("j", "down")),
@@ -442,23 +382,41 @@ func DefaultKeyMap() KeyMap {
 		PageUp:   key.NewBinding(key.WithKeys("K", "pgup"), key.WithHelp("pgup", "page up")),
 		PageDown: key.NewBinding(key.WithKeys("J", "pgdown"), key.WithHelp("pgdown", "page down")),
 		Back:     key.NewBinding(key.WithKeys("h", "backspace", "left", "esc"), key.WithHelp("h", "back")),
-		Open:     key.NewBinding(key.WithKeys("l", "right", "enter"), key.WithHelp("l", "open")),
+		Open:     key.NewBinding(key.WithKeys("l", "right"), key.WithHelp("l", "open")),
 		Select:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+		Retry:    key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "retry")),
+		Sort:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+
+		MarkUp:       key.NewBinding(key.WithKeys("shift+up"), key.WithHelp("shift+↑", "mark up")),
+		MarkDown:     key.NewBinding(key.WithKeys("shift+down"), key.WithHelp("shift+↓", "mark down")),
+		MarkChildren: key.NewBinding(key.WithKeys("*"), key.WithHelp("*", "mark children")),
+
+		Filter:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+		ClearFilter:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear filter")),
+		AcceptFilter: key.NewBinding(key.WithKeys("enter", "tab"), key.WithHelp("enter", "apply filter")),
+
+		Help: key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
 	}
 }
 
 func New() tea.Model {
 	t := Tree{
-		OpenChildrenSymbol:   ChevronDown,
-		ClosedChildrenSymbol: ChevronRight,
-		KeyMap:               DefaultKeyMap(),
+		KeyMap: DefaultKeyMap(),
 	}
+	t.SetGlyphs(DetectGlyphs())
 	t.setInitialValues()
 	return &t
 }
 
 func (t *Tree) setInitialValues() {
 	t.initialized = true
+	t.filterInput = textinput.New()
+	t.filterInput.Prompt = "/"
+	t.spinner = spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	t.helpView = help.New()
+	t.Styles = DefaultStyles()
+	t.HelpOverlay = true
+	t.focus = true
 }
 
 // Returning nil here means you can't go "up" outside of the tree widget, so if this widget is embedded with others,
@@ -471,18 +429,14 @@ func (t *Tree) AddChildren(i ...*TreeItem) ItemHolder {
 	if len(i) == 0 {
 		return t
 	}
-	t.Lock()
-	t.Items = append(t.Items, i...)
-	t.Unlock()
-	// After we add the items, if we didn't have an active item, let's make it the first
-	// one in the list
-	if t.ActiveItem == nil {
-		t.ActiveItem = t.Items[0]
-	}
-	for _, item := range i {
-		item.Parent = t
-		item.ParentTree = t
+	t.insert(len(t.Items), i)
+	if t.rowsValid && t.filterShown == nil && t.lessFor(nil) == nil {
+		// New top level items go at the end, so their rows can just be tacked on
+		t.rows = t.appendVisible(t.rows, i, 0)
+	} else {
+		t.Invalidate()
 	}
+	t.ScrollToActive()
 	return t
 }
 
@@ -498,55 +452,232 @@ func (t *Tree) Init() tea.Cmd {
 	return nil
 }
 
-// SelectPrevious - selects the previous TreeItem. This involves first getting the parent and then telling the parent to select the previous item from the current selection. If we're already at the first child, then we go to the grandparent and select the previous parent item from us, and then we descend to the most open child and activate that.rune
+// SelectPrevious - selects the previous visible row. If the row above us is an open sibling,
+// this will be the deepest last descendant of that sibling.
 func (t *Tree) SelectPrevious() {
+	t.moveCursor(-1)
+}
+
+// SelectNext is like SelectPrevious, but the other way
+func (t *Tree) SelectNext() {
+	t.moveCursor(1)
+}
+
+// SelectFirst activates the very first item in the tree and scrolls back to the top
+func (t *Tree) SelectFirst() {
+	rows := t.visibleItems()
+	if len(rows) == 0 {
+		return
+	}
+	t.SetActive(rows[0])
+}
+
+// SelectLast activates the last visible item of the tree
+func (t *Tree) SelectLast() {
+	rows := t.visibleItems()
+	if len(rows) == 0 {
+		return
+	}
+	t.SetActive(rows[len(rows)-1])
+}
+
+// PageUp moves the cursor up by one screen
+func (t *Tree) PageUp() {
+	t.moveCursor(-t.pageSize())
+}
+
+// PageDown moves the cursor down by one screen
+func (t *Tree) PageDown() {
+	t.moveCursor(t.pageSize())
+}
+
+func (t *Tree) pageSize() int {
+	if h := t.listHeight(); h > 1 {
+		return h - 1
+	}
+	return 1
+}
+
+// Back closes the active item if it is open, otherwise it moves the cursor up to the parent item
+func (t *Tree) Back() {
 	active := t.ActiveItem
-	if active != nil {
-		active.SelectPrevious()
+	if active == nil {
+		return
+	}
+	if active.CanHaveChildren && active.Open {
+		active.ToggleChildren()
 		return
 	}
+	if par, ok := active.Parent.(*TreeItem); ok {
+		t.SetActive(par)
+	}
 }
 
-// SelectNext is like SelectPrevious, but the other way
-func (t *Tree) SelectNext() {
+// OpenChild opens the active item if it is closed, otherwise it moves the cursor down into its
+// first child
+func (t *Tree) OpenChild() {
 	active := t.ActiveItem
-	if active != nil {
-		active.SelectNext()
+	if active != nil && active.placeholder != statusNone {
+		t.LoadMore(active)
+		return
+	}
+	if active == nil || !active.CanHaveChildren {
+		return
+	}
+	if !active.Open {
+		active.ToggleChildren()
 		return
 	}
+	if len(active.Children) > 0 {
+		t.SelectNext()
+	}
+}
+
+// Select activates the current item according to the tree's SelectAction. If the parent model
+// should be told, the returned command delivers an ItemSelectedMsg.
+func (t *Tree) Select() tea.Cmd {
+	active := t.ActiveItem
+	if active == nil {
+		return nil
+	}
+	if active.placeholder != statusNone {
+		t.LoadMore(active)
+		return nil
+	}
+	if t.SelectAction != SelectEmit {
+		active.ToggleChildren()
+	}
+	if t.SelectAction != SelectToggle {
+		return selectedCmd(active)
+	}
+	return nil
 }
 
 // ToggleChild will toggle the open/closed state of the current selection. This only has meaning if there
 // are actually children
 func (t *Tree) ToggleChild() {
+	if t.ActiveItem != nil && t.ActiveItem.placeholder != statusNone {
+		t.LoadMore(t.ActiveItem)
+		return
+	}
 	if t.ActiveItem != nil {
 		t.ActiveItem.ToggleChildren()
 	}
 }
 func (t *Tree) Refresh() {
 	t.Items = []*TreeItem{}
+	t.Invalidate()
+	t.ScrollToActive()
 }
 
 func (t *Tree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmd := t.update(msg)
+	if pending := t.FlushCmds(); pending != nil {
+		cmd = tea.Batch(pending, cmd)
+	}
+	return t, cmd
+}
+
+func (t *Tree) update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		// TODO: Do I take into account margin & border?
 		t.Width = msg.Width
 		t.Height = msg.Height
 		t.initialized = true
+		t.ScrollToActive()
+
+	case topLoadedMsg:
+		if msg.tree == t {
+			return t.topLoaded(msg)
+		}
+		return nil
+
+	case ChildrenLoadedMsg:
+		if msg.Item != nil && msg.Item.ParentTree == t {
+			t.childrenLoaded(msg)
+			if t.restoring != nil {
+				t.applyState(t.restoring)
+			}
+		}
+		return nil
+
+	case spinner.TickMsg:
+		return t.spin(msg)
+
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress && t.contains(msg.X, msg.Y) {
+			t.restoring = nil // The user has taken over
+		}
+		return t.handleMouse(msg)
 
-	// TODO: Convert these simple strings to a configurable keymap
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "?":
-			log.Println("info")
-		case "up", "k":
-			t.SelectPrevious()
-		case "down", "j":
-			t.SelectNext()
-		case " ", ".":
-			t.ToggleChild()
-			return t, nil
+		if !t.focus {
+			return nil
+		}
+		t.restoring = nil
+		if t.showingHelp {
+			// The overlay covers the tree, so keys don't do anything but close it
+			if key.Matches(msg, t.KeyMap.Help) || key.Matches(msg, t.KeyMap.ClearFilter) {
+				t.ToggleHelp()
+			}
+			return nil
+		}
+		if t.filterState == Filtering {
+			return t.updateFilter(msg)
+		}
+		switch {
+		case key.Matches(msg, t.KeyMap.Filter):
+			return t.StartFilter()
+		case t.filterState == FilterApplied && key.Matches(msg, t.KeyMap.ClearFilter):
+			t.ClearFilter()
+		case t.HelpOverlay && key.Matches(msg, t.KeyMap.Help):
+			t.ToggleHelp()
+		case key.Matches(msg, t.KeyMap.Up):
+			if !t.moveCursor(-1) {
+				return t.reachedTop()
+			}
+		case key.Matches(msg, t.KeyMap.Down):
+			if !t.moveCursor(1) {
+				return t.reachedBottom()
+			}
+		case key.Matches(msg, t.KeyMap.GoToTop):
+			t.SelectFirst()
+		case key.Matches(msg, t.KeyMap.GoToLast):
+			t.SelectLast()
+		case key.Matches(msg, t.KeyMap.PageUp):
+			if !t.moveCursor(-t.pageSize()) {
+				return t.reachedTop()
+			}
+		case key.Matches(msg, t.KeyMap.PageDown):
+			if !t.moveCursor(t.pageSize()) {
+				return t.reachedBottom()
+			}
+		case key.Matches(msg, t.KeyMap.Retry):
+			t.Retry()
+		case len(t.Columns) > 0 && key.Matches(msg, t.KeyMap.Sort):
+			t.CycleSort()
+		case key.Matches(msg, t.KeyMap.Back):
+			t.Back()
+		case key.Matches(msg, t.KeyMap.Select):
+			return t.Select()
+		case key.Matches(msg, t.KeyMap.Open):
+			t.OpenChild()
+		case t.MultiSelect && key.Matches(msg, t.KeyMap.MarkUp):
+			t.markAndMove(-1)
+		case t.MultiSelect && key.Matches(msg, t.KeyMap.MarkDown):
+			t.markAndMove(1)
+		case t.MultiSelect && key.Matches(msg, t.KeyMap.MarkChildren):
+			t.ToggleMarkChildren()
+		case key.Matches(msg, t.KeyMap.Space):
+			if t.Checkboxes {
+				return t.ToggleCheck()
+			}
+			if t.MultiSelect {
+				t.ToggleMark()
+			} else {
+				t.ToggleChild()
+			}
+			return nil
 		}
 	}
 
@@ -556,45 +687,81 @@ func (t *Tree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		i, cmd = t.ActiveItem.Update(msg)
 		t.ActiveItem = i.(*TreeItem)
 	}
-	return t, cmd
+	return cmd
+}
+
+// Focus lets the tree respond to keys, and draws the cursor with the focused style. Trees are
+// focused when they are created.
+func (t *Tree) Focus() {
+	t.focus = true
+}
+
+// Blur stops the tree from responding to keys, so several trees can share the screen with only
+// one of them taking input. The cursor is drawn with a dimmed style. Mouse events are still
+// handled, since the host decides which tree they're meant for.
+func (t *Tree) Blur() {
+	t.focus = false
+}
+
+// Focused reports whether the tree is taking key input
+func (t *Tree) Focused() bool {
+	return t.focus
 }
 
+// cursorStyle is the style for the active row, which depends on whether we have focus
+func (t *Tree) cursorStyle() lipgloss.Style {
+	if t.focus {
+		return t.Styles.Cursor
+	}
+	return t.Styles.BlurredCursor
+}
+
+// SetActive moves the cursor to the given item, scrolling the view if needed so it is on screen
 func (t *Tree) SetActive(ti *TreeItem) {
 	t.ActiveItem = ti
+	t.ScrollToActive()
 }
 
 // ScrollDown moves the "display" area down the virtual list. This actually looks like scrolling up ((the items move up the screen) Not sure if this is counterintuitive or not
+// The cursor doesn't move, so it may end up off screen.
 func (t *Tree) ScrollDown(n int) {
-	t.viewtop += n
+	t.scrollBy(n)
 }
 
 func (t *Tree) ScrollUp(n int) {
-	t.viewtop -= n
+	t.scrollBy(-n)
 }
 
 func (t *Tree) View() string {
 	if !t.initialized {
 		return ""
 	}
+	if t.showingHelp {
+		return t.helpOverlay()
+	}
 	var views []string
+	if t.Title != "" {
+		views = append(views, t.Styles.Title.Render(t.Title))
+	}
+	if t.filterState != Unfiltered {
+		views = append(views, t.filterInput.View())
+	}
+	if len(t.Columns) > 0 {
+		views = append(views, t.renderHeader())
+	}
 
-	// Iterate through the children, calling View() on each of them.
-	curline := -t.viewtop
-	bottom := t.Height
-	var v string
-	for _, item := range t.Items {
-		item.indent = 0
-		curline, v = item.ViewScrolled(t.viewtop, curline, bottom)
-		if curline > bottom {
-			break
-		}
-		if v != "" {
-			views = append(views, v)
-		}
+	// Only the rows that are on screen get rendered
+	rows := t.visibleItems()
+	bottom := len(rows)
+	if h := t.listHeight(); h > 0 && t.viewtop+h < bottom {
+		bottom = t.viewtop + h
+	}
+	for x := t.viewtop; x < bottom; x++ {
+		views = append(views, rows[x].renderRow())
 	}
 
 	s := lipgloss.JoinVertical(
 		lipgloss.Left, views...,
 	)
-	return s
+	return t.frame(s)
 }
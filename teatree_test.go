@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -68,6 +70,106 @@ func TestSymbols(t *testing.T) {
 	fmt.Println(redstyle.Render(Organization), "Organization")
 }
 
+// newTestTree builds a tree of closed folders: Item 1..Item n, each with children A, B and C
+func newTestTree(n, height int) *Tree {
+	tr := New().(*Tree)
+	tr.Update(tea.WindowSizeMsg{Width: 40, Height: height})
+	for x := 1; x <= n; x++ {
+		item := NewItem(fmt.Sprintf("Item %d", x), true, nil, nil, nil, nil, nil, nil, nil)
+		tr.AddChildren(item)
+		for _, name := range []string{"A", "B", "C"} {
+			item.AddChildren(NewItem(name, false, nil, nil, nil, nil, nil, nil, nil))
+		}
+	}
+	return tr
+}
+
+func keyMsg(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestKeyMap(t *testing.T) {
+	tr := newTestTree(3, 10)
+
+	tr.Update(keyMsg("G"))
+	if tr.ActiveItem.Name != "Item 3" {
+		t.Fatalf("GoToLast: expected Item 3, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(keyMsg("g"))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("GoToTop: expected Item 1, got %s", tr.ActiveItem.Name)
+	}
+
+	tr.Update(keyMsg("l"))
+	if !tr.ActiveItem.Open {
+		t.Fatalf("Open: expected Item 1 to be open")
+	}
+	tr.Update(keyMsg("l"))
+	if tr.ActiveItem.Name != "A" {
+		t.Fatalf("Open: expected to move into A, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(keyMsg("h"))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("Back: expected to move up to Item 1, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(keyMsg("h"))
+	if tr.ActiveItem.Open {
+		t.Fatalf("Back: expected Item 1 to be closed")
+	}
+
+	// Remapped and disabled bindings
+	tr.KeyMap.Down = key.NewBinding(key.WithKeys("n"))
+	tr.Update(keyMsg("j"))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected j to be unbound, got %s", tr.ActiveItem.Name)
+	}
+	tr.Update(keyMsg("n"))
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected n to move down, got %s", tr.ActiveItem.Name)
+	}
+	tr.KeyMap.Down.SetEnabled(false)
+	tr.Update(keyMsg("n"))
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected disabled binding to do nothing, got %s", tr.ActiveItem.Name)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	tr := newTestTree(3, 10)
+	if !tr.Focused() {
+		t.Fatal("expected a new tree to be focused")
+	}
+
+	tr.Blur()
+	if tr.Focused() {
+		t.Fatal("expected Blur to drop focus")
+	}
+	if tr.cursorStyle().GetBackground() != tr.Styles.BlurredCursor.GetBackground() {
+		t.Fatal("expected the cursor to be dimmed while blurred")
+	}
+	tr.Update(keyMsg("j"))
+	if tr.ActiveItem.Name != "Item 1" {
+		t.Fatalf("expected keys to be ignored while blurred, got %s", tr.ActiveItem.Name)
+	}
+
+	tr.Focus()
+	if tr.cursorStyle().GetBackground() != tr.Styles.Cursor.GetBackground() {
+		t.Fatal("expected the cursor to be highlighted once focused")
+	}
+	tr.Update(keyMsg("j"))
+	if tr.ActiveItem.Name != "Item 2" {
+		t.Fatalf("expected j to move down once focused, got %s", tr.ActiveItem.Name)
+	}
+}
+
 /*
 func TestTree(t *testing.T) {
 	m := New()
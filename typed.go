@@ -0,0 +1,216 @@
+package teatree
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TypedItem is a TreeItem whose Data is always a T, so its callbacks and lookups don't need type
+// assertions. It's a thin wrapper: the TreeItem underneath is an ordinary one, and everything in
+// the package that takes a *TreeItem can be given it.
+//
+// Go won't let a generic type share a name with TreeItem, hence the names TypedItem and TypedTree.
+type TypedItem[T any] struct {
+	*TreeItem
+}
+
+// TypedTree is a Tree whose items all carry a T, see TypedItem. Its Update sends a
+// TypedSelectedMsg in place of an ItemSelectedMsg.
+type TypedTree[T any] struct {
+	*Tree
+}
+
+// TypedSelectedMsg is what a TypedTree sends when the user activates an item with the Select key
+type TypedSelectedMsg[T any] struct {
+	Item  TypedItem[T]
+	Path  []string // Item.GetPath() at the time it was selected
+	Value T
+}
+
+// ValueOf returns the T an item carries in its Data. ok is false, and the zero T is returned, if
+// the Data isn't a T. This is handy in the callbacks that take a plain *TreeItem, like
+// Column.Value.
+func ValueOf[T any](ti *TreeItem) (value T, ok bool) {
+	if ti == nil {
+		return value, false
+	}
+	value, ok = ti.Data.(T)
+	return value, ok
+}
+
+// NewTypedItem is NewItem for a TypedItem, with callbacks that are given the TypedItem
+func NewTypedItem[T any](name string, canHaveChildren bool, children []TypedItem[T], icon func(TypedItem[T]) string, labelStyle, iconStyle func(TypedItem[T]) lipgloss.Style, openFunc, closeFunc func(TypedItem[T]), value T) TypedItem[T] {
+	ti := TypedItem[T]{NewItem(name, canHaveChildren, nil, nil, nil, nil, nil, nil, value)}
+	if icon != nil {
+		ti.icon = func(ti *TreeItem) string {
+			return icon(TypedItem[T]{ti})
+		}
+	}
+	ti.labelStyle = typedStyle(labelStyle)
+	ti.iconStyle = typedStyle(iconStyle)
+	ti.OpenFunc = typedFunc(openFunc)
+	ti.CloseFunc = typedFunc(closeFunc)
+	if len(children) > 0 {
+		ti.AddChildren(children...)
+	}
+	return ti
+}
+
+func typedStyle[T any](style func(TypedItem[T]) lipgloss.Style) func(*TreeItem) lipgloss.Style {
+	if style == nil {
+		return nil
+	}
+	return func(ti *TreeItem) lipgloss.Style {
+		return style(TypedItem[T]{ti})
+	}
+}
+
+func typedFunc[T any](f func(TypedItem[T])) func(*TreeItem) {
+	if f == nil {
+		return nil
+	}
+	return func(ti *TreeItem) {
+		f(TypedItem[T]{ti})
+	}
+}
+
+func typedLess[T any](less func(a, b TypedItem[T]) bool) func(a, b *TreeItem) bool {
+	if less == nil {
+		return nil
+	}
+	return func(a, b *TreeItem) bool {
+		return less(TypedItem[T]{a}, TypedItem[T]{b})
+	}
+}
+
+// typedItems wraps a list of items
+func typedItems[T any](items []*TreeItem) []TypedItem[T] {
+	typed := make([]TypedItem[T], 0, len(items))
+	for _, item := range items {
+		typed = append(typed, TypedItem[T]{item})
+	}
+	return typed
+}
+
+// untypedItems unwraps a list of items
+func untypedItems[T any](items []TypedItem[T]) []*TreeItem {
+	plain := make([]*TreeItem, 0, len(items))
+	for _, item := range items {
+		plain = append(plain, item.TreeItem)
+	}
+	return plain
+}
+
+// Value returns the T the item carries, or the zero T if its Data has been set to something else
+func (ti TypedItem[T]) Value() T {
+	value, _ := ValueOf[T](ti.TreeItem)
+	return value
+}
+
+// SetValue changes the T the item carries
+func (ti TypedItem[T]) SetValue(value T) {
+	ti.Data = value
+}
+
+// ChildItems returns the item's children
+func (ti TypedItem[T]) ChildItems() []TypedItem[T] {
+	return typedItems[T](ti.Children)
+}
+
+// ParentItem returns the item's parent. ok is false for a top level item.
+func (ti TypedItem[T]) ParentItem() (parent TypedItem[T], ok bool) {
+	par, ok := ti.Parent.(*TreeItem)
+	return TypedItem[T]{par}, ok
+}
+
+// AddChildren adds children to the item, like TreeItem.AddChildren, and returns the item
+func (ti TypedItem[T]) AddChildren(children ...TypedItem[T]) TypedItem[T] {
+	ti.TreeItem.AddChildren(untypedItems(children)...)
+	return ti
+}
+
+// SetLoader loads the item's children in the background, the first time it is opened, with
+// load. See AsyncLoader.
+func (ti TypedItem[T]) SetLoader(load func(TypedItem[T]) ([]TypedItem[T], error)) {
+	ti.LoadFunc = AsyncLoader(func(item *TreeItem) ([]*TreeItem, error) {
+		children, err := load(TypedItem[T]{item})
+		return untypedItems(children), err
+	})
+}
+
+// SetLess orders the item's children, see TreeItem.Less
+func (ti TypedItem[T]) SetLess(less func(a, b TypedItem[T]) bool) {
+	ti.Less = typedLess(less)
+}
+
+// NewTyped creates a tree for items that carry a T
+func NewTyped[T any]() TypedTree[T] {
+	return Typed[T](New().(*Tree))
+}
+
+// Typed wraps an existing tree, whose items carry a T
+func Typed[T any](t *Tree) TypedTree[T] {
+	return TypedTree[T]{t}
+}
+
+// Update is Tree.Update, except that selecting an item sends a TypedSelectedMsg
+func (t TypedTree[T]) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, cmd := t.Tree.Update(msg)
+	return t, typedCmd[T](cmd)
+}
+
+// typedCmd turns the ItemSelectedMsg that cmd produces, if it does, into a TypedSelectedMsg
+func typedCmd[T any](cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case ItemSelectedMsg:
+			item := TypedItem[T]{msg.Item}
+			return TypedSelectedMsg[T]{Item: item, Path: msg.Path, Value: item.Value()}
+		case tea.BatchMsg:
+			for x, c := range msg {
+				msg[x] = typedCmd[T](c)
+			}
+			return msg
+		default:
+			return msg
+		}
+	}
+}
+
+// AddChildren adds top level items to the tree, like Tree.AddChildren, and returns the tree
+func (t TypedTree[T]) AddChildren(items ...TypedItem[T]) TypedTree[T] {
+	t.Tree.AddChildren(untypedItems(items)...)
+	return t
+}
+
+// TopItems returns the top level items
+func (t TypedTree[T]) TopItems() []TypedItem[T] {
+	return typedItems[T](t.Items)
+}
+
+// Active returns the active item. ok is false if there isn't one, or the cursor is on a loading
+// or "load more" row.
+func (t TypedTree[T]) Active() (item TypedItem[T], ok bool) {
+	ti := t.ActiveItem
+	if ti == nil || ti.placeholder != statusNone {
+		return item, false
+	}
+	return TypedItem[T]{ti}, true
+}
+
+// Find returns the item at path, (see GetPath), if it has been loaded
+func (t TypedTree[T]) Find(path ...string) (item TypedItem[T], ok bool) {
+	ti, _ := t.lookup(path, lookupFind)
+	if ti == nil {
+		return item, false
+	}
+	return TypedItem[T]{ti}, true
+}
+
+// SetLess keeps each group of siblings in order, see Tree.Less
+func (t TypedTree[T]) SetLess(less func(a, b TypedItem[T]) bool) {
+	t.Less = typedLess(less)
+}
@@ -0,0 +1,93 @@
+package teatree
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type planet struct {
+	moons int
+}
+
+func TestTypedTree(t *testing.T) {
+	tr := NewTyped[planet]()
+	tr.Height = 10
+	icon := func(ti TypedItem[planet]) string {
+		if ti.Value().moons > 0 {
+			return "o"
+		}
+		return "."
+	}
+	bold := func(ti TypedItem[planet]) lipgloss.Style {
+		return lipgloss.NewStyle().Bold(ti.Value().moons > 1)
+	}
+	var opened []int
+	open := func(ti TypedItem[planet]) {
+		opened = append(opened, ti.Value().moons)
+	}
+	earth := NewTypedItem("Earth", true, nil, icon, bold, nil, open, nil, planet{moons: 1})
+	mars := NewTypedItem("Mars", false, nil, icon, bold, nil, nil, nil, planet{moons: 2})
+	sun := NewTypedItem("Sun", true, []TypedItem[planet]{earth, mars}, icon, nil, nil, nil, nil, planet{})
+	tr.SetLess(func(a, b TypedItem[planet]) bool { return a.Value().moons > b.Value().moons })
+	tr.AddChildren(sun)
+
+	if got := joined(sun.Children); got != "Mars Earth" {
+		t.Fatalf("expected the typed Less to order the children, got %s", got)
+	}
+	found, ok := tr.Find("Sun", "Earth")
+	if !ok || found.TreeItem != earth.TreeItem || found.Icon() != "o" || found.Value().moons != 1 {
+		t.Fatal("expected to find Earth with its typed callbacks")
+	}
+	if parent, ok := found.ParentItem(); !ok || parent.TreeItem != sun.TreeItem {
+		t.Fatal("expected Earth's parent to be the Sun")
+	}
+	if _, ok := sun.ParentItem(); ok {
+		t.Fatal("expected a top level item to have no parent")
+	}
+	found.ToggleChildren()
+	if !reflect.DeepEqual(opened, []int{1}) {
+		t.Fatalf("expected the typed OpenFunc to run, got %v", opened)
+	}
+
+	// Selecting sends a typed message
+	sun.ToggleChildren()
+	tr.SetActive(mars.TreeItem)
+	_, cmd := tr.Update(keyMsg("enter"))
+	var selected TypedSelectedMsg[planet]
+	for _, msg := range runCmd(cmd) {
+		if msg, ok := msg.(TypedSelectedMsg[planet]); ok {
+			selected = msg
+		}
+	}
+	if selected.Item.TreeItem != mars.TreeItem || selected.Value.moons != 2 || !reflect.DeepEqual(selected.Path, []string{"Sun", "Mars"}) {
+		t.Fatalf("expected a TypedSelectedMsg for Mars, got %+v", selected)
+	}
+	if active, ok := tr.Active(); !ok || active.Value().moons != 2 {
+		t.Fatal("expected Mars to be active")
+	}
+}
+
+func TestTypedLoader(t *testing.T) {
+	tr := NewTyped[int]()
+	root := NewTypedItem[int]("root", true, nil, nil, nil, nil, nil, nil, 0)
+	root.SetLoader(func(ti TypedItem[int]) ([]TypedItem[int], error) {
+		n := ti.Value()
+		return []TypedItem[int]{NewTypedItem[int]("child", false, nil, nil, nil, nil, nil, nil, n+1)}, nil
+	})
+	tr.AddChildren(root)
+	root.ToggleChildren()
+	deliver(t, tr.Tree, tr.FlushCmds())
+	if children := root.ChildItems(); len(children) != 1 || children[0].Value() != 1 {
+		t.Fatalf("expected a typed child carrying 1, got %v", names(root.Children))
+	}
+
+	// An item that was given something else just has the zero value
+	root.Data = "not an int"
+	if _, ok := ValueOf[int](root.TreeItem); ok || root.Value() != 0 {
+		t.Fatal("expected ValueOf to report the wrong type")
+	}
+	var _ tea.Model = tr
+}